@@ -0,0 +1,178 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+)
+
+// BackupResult 一次备份的结果
+type BackupResult struct {
+	Record  *database.BackupRecord // 新建的备份记录 跳过时为 nil
+	Skipped bool                   // 存档没有变化 restic 没有创建新的快照
+	Parent  string                 // 父快照 跳过时即为与存档内容一致的快照
+}
+
+// BackupArchive 备份存档的完整流程
+// 先检查存储配额并淘汰旧快照 再执行 restic 备份 所有进度都通过通道返回
+func BackupArchive(a *database.Archive, parent string) <-chan *BackupMessage {
+	outputChan := make(chan *BackupMessage, 100)
+
+	go func() {
+		defer close(outputChan)
+
+		repoMu.RLock()
+		defer repoMu.RUnlock()
+
+		// 1. 存储配额
+		if a.Quota > 0 {
+			outputChan <- &BackupMessage{MessageType: "info", Message: "正在检查存储配额..."}
+
+			evicted, err := EnforceQuota(a, parent)
+			if err != nil {
+				outputChan <- &BackupMessage{MessageType: "error", Message: err.Error(), Code: 1}
+				return
+			}
+			if len(evicted) > 0 {
+				outputChan <- &BackupMessage{
+					MessageType: "info",
+					Message:     fmt.Sprintf("超出存储配额，已淘汰 %d 个最旧的快照", len(evicted)),
+				}
+			}
+		}
+
+		// 2. 世界正在被使用时 依靠卷影副本保证一致性
+		var inUse, _ = IsArchiveInUse(a)
+		if inUse {
+			outputChan <- &BackupMessage{MessageType: "info", Message: "存档正在被使用，将通过卷影副本备份当前已保存的状态"}
+		}
+
+		// 3. 检查存档完整性 结果记录在摘要中 发现问题时仍然备份
+		// 存档正在被使用时游戏随时可能写入 读到写了一半的文件会误报损坏 因此跳过
+		var send = func(msg *BackupMessage) { outputChan <- msg }
+		if a.ScanBeforeBackup && CanScanArchive(a) && inUse {
+			outputChan <- &BackupMessage{MessageType: "info", Message: "存档正在被使用，本次跳过完整性检查"}
+		} else if a.ScanBeforeBackup && CanScanArchive(a) {
+			outputChan <- &BackupMessage{MessageType: "info", Message: "正在检查存档完整性..."}
+			report, err := world.ScanWorld(a.BackupPaths()...)
+			if err != nil {
+				outputChan <- &BackupMessage{MessageType: "error", Message: err.Error(), Code: 1}
+				return
+			}
+			var health = HealthOf(report)
+			outputChan <- &BackupMessage{MessageType: "info", Message: ScanSummary(report)}
+
+			send = func(msg *BackupMessage) {
+				if msg.MessageType == "summary" {
+					msg.Health, msg.HealthReport = health, HealthReportText(report)
+				}
+				outputChan <- msg
+			}
+		}
+
+		// 4. 规范化区域文件 复制过程中游戏可能写入 存档正在被使用时直接备份
+		if a.NormalizeRegions && CanNormalizeRegions(a) {
+			if inUse {
+				outputChan <- &BackupMessage{MessageType: "info", Message: "存档正在被使用，本次跳过区域文件规范化"}
+			} else {
+				backupNormalized(a, parent, send)
+				return
+			}
+		}
+
+		// 5. 执行备份
+		for msg := range ResticBackup(a, parent) {
+			send(msg)
+		}
+	}()
+
+	return outputChan
+}
+
+// backupNormalized 备份规范化后的暂存副本 并与最近几次直接备份的新增数据量比较
+func backupNormalized(a *database.Archive, parent string, send func(*BackupMessage)) {
+	send(&BackupMessage{MessageType: "info", Message: "正在复制存档并规范化区域文件..."})
+	roots, err := stageNormalized(a)
+	if err != nil {
+		send(&BackupMessage{MessageType: "error", Message: err.Error(), Code: 1})
+		return
+	}
+	defer removeStaging(a)
+
+	for msg := range resticBackup(a, roots, parent) {
+		if msg.MessageType == "summary" {
+			msg.Normalized = true
+			msg.Message = fmt.Sprintf("规范化后新增 %.2f MB", float64(msg.DataAdded)/1048576)
+			// 查询失败只影响对比 不影响备份
+			if average, count, err := AveragePlainDataAdded(a.ID, plainCompareCount); err == nil && count > 0 {
+				msg.Message += fmt.Sprintf("，最近 %d 次直接备份平均新增 %.2f MB", count, float64(average)/1048576)
+			}
+		}
+		send(msg)
+	}
+}
+
+// FinishBackup 根据备份的摘要信息写入备份记录
+// 存档没有变化时 restic 不会创建快照 也不会写入记录 但仍然视为成功
+func FinishBackup(a *database.Archive, parent, comment string, summary *BackupMessage) (*BackupResult, error) {
+	if summary == nil || summary.MessageType != "summary" {
+		return nil, errors.New("备份没有返回摘要信息")
+	}
+	if summary.Skipped() {
+		return &BackupResult{Skipped: true, Parent: parent}, nil
+	}
+
+	var record = &database.BackupRecord{
+		ArchiveID:    a.ID,
+		SnapShot:     summary.SnapshotID,
+		Comment:      comment,
+		Parent:       parent,
+		DataAdded:    summary.DataAdded,
+		Duration:     summary.TotalDuration,
+		Normalized:   summary.Normalized,
+		Health:       summary.Health,
+		HealthReport: summary.HealthReport,
+	}
+	// 备份完成时存档的游戏版本 回档前用于判断是否会降级
+	record.DataVersion, record.VersionName, _ = readGameVersion(a)
+	// 模组与数据包不一定在备份路径中 单独记录清单
+	record.Manifest = manifestJSON(a)
+	if err := CreateBackupRecord(record); err != nil {
+		return nil, err
+	}
+
+	return &BackupResult{Record: record, Parent: parent}, nil
+}
+
+// RunBackup 不依赖界面的同步备份 供定时任务等自动流程使用
+// 存档没有变化时返回 Skipped == true 且没有错误
+func RunBackup(a *database.Archive, comment string) (*BackupResult, error) {
+	// 以最近一次成功的备份作为父快照
+	var parent string
+	latest, err := GetLatestBackupRecordByArchiveID(a.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询父快照失败: %w", err)
+	}
+	if latest != nil {
+		parent = latest.SnapShot
+	}
+
+	// 读完所有消息 避免备份协程阻塞
+	var summary *BackupMessage
+	var backupErr error
+	for msg := range BackupArchive(a, parent) {
+		switch {
+		case msg.MessageType == "summary":
+			summary = msg
+		case backupErr == nil && (msg.Code != 0 || (msg.MessageType == "error" && msg.Message != "")):
+			backupErr = errors.New(msg.Message)
+		}
+	}
+
+	if backupErr != nil {
+		return nil, backupErr
+	}
+
+	return FinishBackup(a, parent, comment, summary)
+}
@@ -7,27 +7,113 @@ import (
 	"minecraft-archive-backup/model/dto/database"
 )
 
-// CreateArchive 创建一个存档
+// CreateArchive 创建一个存档 附加路径会一并写入
 func CreateArchive(archive *database.Archive) error {
-	result := DB.Create(archive)
-	return WrapUniqueConstraintError(result.Error)
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkArchivePaths(tx, archive); err != nil {
+			return err
+		}
+		result := tx.Create(archive)
+		return WrapUniqueConstraintError(result.Error)
+	})
+	if err != nil {
+		return err
+	}
+
+	resyncGameVersions()
+	return nil
 }
 
-// UpdateArchive 更新一个存档
+// UpdateArchive 更新一个存档 附加路径以传入的列表为准
 func UpdateArchive(archive *database.Archive) error {
-	result := DB.Save(archive)
-	return WrapUniqueConstraintError(result.Error)
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkArchivePaths(tx, archive); err != nil {
+			return err
+		}
+
+		result := tx.Omit("ExtraPaths").Save(archive)
+		if result.Error != nil {
+			return WrapUniqueConstraintError(result.Error)
+		}
+
+		// 先删除旧的附加路径 再重新写入
+		result = tx.Where("archive_id = ?", archive.ID).Delete(&database.ArchivePath{})
+		if result.Error != nil {
+			return fmt.Errorf("更新附加路径失败: %w", result.Error)
+		}
+		for i := range archive.ExtraPaths {
+			archive.ExtraPaths[i].ID = 0
+			archive.ExtraPaths[i].ArchiveID = archive.ID
+		}
+		if len(archive.ExtraPaths) > 0 {
+			result = tx.Create(&archive.ExtraPaths)
+			if result.Error != nil {
+				return WrapUniqueConstraintError(result.Error)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 路径或类型可能变化 需要监听的世界随之变化
+	resyncGameVersions()
+	return nil
+}
+
+// checkArchivePaths 检查存档的路径没有重复 也没有被其他存档使用
+// Windows 的路径不区分大小写 因此不能只依赖数据库的唯一约束
+func checkArchivePaths(tx *gorm.DB, archive *database.Archive) error {
+	var used = make(map[string]bool)
+	for _, p := range archive.BackupPaths() {
+		if used[pathKey(p)] {
+			return fmt.Errorf("路径重复: %s", p)
+		}
+		used[pathKey(p)] = true
+	}
+
+	var others []string
+	if err := tx.Model(&database.Archive{}).Where("id <> ?", archive.ID).Pluck("path", &others).Error; err != nil {
+		return err
+	}
+	var extras []string
+	if err := tx.Model(&database.ArchivePath{}).Where("archive_id <> ?", archive.ID).Pluck("path", &extras).Error; err != nil {
+		return err
+	}
+
+	for _, p := range append(others, extras...) {
+		if used[pathKey(p)] {
+			return fmt.Errorf("路径已被其他存档使用: %s", p)
+		}
+	}
+	return nil
 }
 
 // DeleteArchive 删除一个存档，
 func DeleteArchive(id uint) error {
 	// 使用事务确保数据一致性
-	return DB.Transaction(func(tx *gorm.DB) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
 		result := tx.Where("archive_id = ?", id).Delete(&database.BackupRecord{})
 		if result.Error != nil {
 			return fmt.Errorf("删除备份记录失败: %w", result.Error)
 		}
 
+		result = tx.Where("archive_id = ?", id).Delete(&database.QuotaEviction{})
+		if result.Error != nil {
+			return fmt.Errorf("删除配额淘汰记录失败: %w", result.Error)
+		}
+
+		result = tx.Where("archive_id = ?", id).Delete(&database.ArchivePath{})
+		if result.Error != nil {
+			return fmt.Errorf("删除附加路径失败: %w", result.Error)
+		}
+
+		result = tx.Where("archive_id = ?", id).Delete(&database.WorldInfo{})
+		if result.Error != nil {
+			return fmt.Errorf("删除存档信息失败: %w", result.Error)
+		}
+
 		result = tx.Delete(&database.Archive{}, id)
 		if result.Error != nil {
 			return fmt.Errorf("删除存档失败: %w", result.Error)
@@ -35,19 +121,25 @@ func DeleteArchive(id uint) error {
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	resyncGameVersions()
+	return nil
 }
 
 // GetAllArchives 查询所有的存档
 func GetAllArchives() ([]database.Archive, error) {
 	var archives []database.Archive
-	result := DB.Find(&archives)
+	result := DB.Preload("ExtraPaths").Find(&archives)
 	return archives, result.Error
 }
 
 // GetArchiveByID 查询指定ID（主键）的存档
 func GetArchiveByID(id uint) (*database.Archive, error) {
 	var archive database.Archive
-	result := DB.First(&archive, id)
+	result := DB.Preload("ExtraPaths").First(&archive, id)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
@@ -0,0 +1,195 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	"os"
+	"path/filepath"
+)
+
+// ArchiveTypeInfo 存档类型的展示信息
+type ArchiveTypeInfo struct {
+	Type        database.ArchiveType
+	Name        string
+	Description string
+}
+
+// ArchiveTypes 所有的存档类型
+var ArchiveTypes = []ArchiveTypeInfo{
+	{Type: database.ArchiveTypeWorld, Name: "世界", Description: "单个存档文件夹 其中包含 level.dat"},
+	{Type: database.ArchiveTypeBedrock, Name: "基岩版世界", Description: "com.mojang/minecraftWorlds 中的存档文件夹 其中包含 db 与 level.dat"},
+	{Type: database.ArchiveTypeInstance, Name: "游戏实例", Description: "整个 .minecraft 或启动器实例 包含模组、配置、资源包与光影"},
+	{Type: database.ArchiveTypeServer, Name: "服务器", Description: "服务器根目录 其中包含 server.properties"},
+	{Type: database.ArchiveTypeFolder, Name: "文件夹", Description: "任意文件夹"},
+}
+
+// instanceMarkers 游戏实例中至少存在其中之一
+var instanceMarkers = []string{"mods", "config", "options.txt", "resourcepacks", "shaderpacks"}
+
+// defaultExcludes 各类型的默认排除规则
+var defaultExcludes = map[database.ArchiveType][]string{
+	database.ArchiveTypeWorld: {
+		"session.lock",
+		"*.tmp",
+	},
+	// 世界单独建立存档 实例中只保留模组与配置
+	database.ArchiveTypeInstance: {
+		"/saves",
+		"/logs",
+		"/crash-reports",
+		"/screenshots",
+		"/assets",
+		"/libraries",
+		"/versions/*/natives",
+		".cache",
+		".fabric",
+		".mixin.out",
+		"*.tmp",
+	},
+	database.ArchiveTypeServer: {
+		"session.lock",
+		"/logs",
+		"/crash-reports",
+		"/cache",
+		"/libraries",
+		"/versions",
+		"plugins/*/logs",
+		"*.tmp",
+	},
+}
+
+// ArchiveTypeName 存档类型的名称
+func ArchiveTypeName(t database.ArchiveType) string {
+	for _, info := range ArchiveTypes {
+		if info.Type == t {
+			return info.Name
+		}
+	}
+	return ArchiveTypes[0].Name
+}
+
+// NormalizeArchiveType 旧数据没有类型 视为世界
+func NormalizeArchiveType(t database.ArchiveType) database.ArchiveType {
+	for _, info := range ArchiveTypes {
+		if info.Type == t {
+			return t
+		}
+	}
+	return database.ArchiveTypeWorld
+}
+
+// DefaultExcludes 存档类型的默认排除规则
+func DefaultExcludes(t database.ArchiveType) []string {
+	return defaultExcludes[NormalizeArchiveType(t)]
+}
+
+// ValidateArchive 根据存档类型检查路径是否正确
+func ValidateArchive(a *database.Archive) error {
+	for _, p := range a.BackupPaths() {
+		info, err := os.Stat(p)
+		if err != nil {
+			return fmt.Errorf("路径不存在: %s", p)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("路径不是文件夹: %s", p)
+		}
+	}
+
+	switch NormalizeArchiveType(a.Type) {
+	case database.ArchiveTypeWorld:
+		if world.IsBedrockWorld(a.Path) {
+			return fmt.Errorf("这是一个基岩版存档，请将类型改为基岩版世界")
+		}
+		if !exists(world.LevelDatPath(a.Path)) {
+			return fmt.Errorf("存档路径中没有 level.dat，请选择世界文件夹，或将类型改为其他类型")
+		}
+	case database.ArchiveTypeBedrock:
+		if !world.IsBedrockWorld(a.Path) {
+			return fmt.Errorf("存档路径中没有 db 文件夹与 level.dat，请选择 minecraftWorlds 中的存档文件夹")
+		}
+	case database.ArchiveTypeInstance:
+		for _, marker := range instanceMarkers {
+			if exists(filepath.Join(a.Path, marker)) {
+				return nil
+			}
+		}
+		return fmt.Errorf("存档路径中没有 mods、config 或 options.txt，请选择 .minecraft 或实例文件夹")
+	case database.ArchiveTypeServer:
+		if !exists(filepath.Join(a.Path, world.ServerPropertiesName)) {
+			return fmt.Errorf("存档路径中没有 server.properties，请选择服务器根目录")
+		}
+	}
+	return nil
+}
+
+// WorldPath 读取 level.dat 所用的世界路径 没有世界的类型返回空字符串
+func WorldPath(a *database.Archive) string {
+	switch NormalizeArchiveType(a.Type) {
+	case database.ArchiveTypeWorld, database.ArchiveTypeBedrock:
+		return a.Path
+	case database.ArchiveTypeServer:
+		return filepath.Join(a.Path, world.ServerLevelName(a.Path))
+	}
+	return ""
+}
+
+// ErrArchiveInUse 存档正在被使用 不能写入
+var ErrArchiveInUse = errors.New("存档正在被使用，请先退出存档或关闭服务器")
+
+// CheckArchiveNotInUse 写入存档之前调用 存档正在被使用或无法确认时返回错误
+func CheckArchiveNotInUse(a *database.Archive) error {
+	inUse, err := IsArchiveInUse(a)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrArchiveInUse
+	}
+	return nil
+}
+
+// IsArchiveInUse 检查存档中的世界是否正在被游戏或服务器使用
+// Java 版检查 session.lock 基岩版检查 LevelDB 的 db/LOCK 实例与文件夹总是返回 false
+// 无法打开或锁定锁文件时 (例如共享冲突、拒绝访问) 视为正在使用 避免写入运行中的世界
+func IsArchiveInUse(a *database.Archive) (bool, error) {
+	var worlds []string
+	switch NormalizeArchiveType(a.Type) {
+	case database.ArchiveTypeBedrock:
+		for _, p := range a.BackupPaths() {
+			inUse, err := world.IsBedrockWorldInUse(p)
+			if err != nil {
+				return true, fmt.Errorf("无法检查存档是否正在被使用: %w", err)
+			}
+			if inUse {
+				return true, nil
+			}
+		}
+		return false, nil
+	case database.ArchiveTypeWorld:
+		worlds = a.BackupPaths()
+	case database.ArchiveTypeServer:
+		for _, p := range a.BackupPaths() {
+			worlds = append(worlds, p)
+			worlds = append(worlds, world.ServerWorlds(p)...)
+		}
+	}
+
+	for _, w := range worlds {
+		inUse, err := world.IsWorldInUse(filepath.Join(w, "session.lock"))
+		if err != nil {
+			return true, fmt.Errorf("无法检查存档是否正在被使用: %w", err)
+		}
+		if inUse {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// exists 判断文件或文件夹是否存在
+func exists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,437 @@
+package archive
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	etc "minecraft-archive-backup/pkg/etc/core"
+	"minecraft-archive-backup/pkg/nbt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportComment 导入的备份记录的备注前缀
+const ImportComment = "导入"
+
+// 文件名中的时间 例如 Minecraft "备份世界" 生成的 2023-05-01_12-30-45_世界.zip 或 20230501-123045.zip
+var importTimePattern = regexp.MustCompile(`(20\d{2})[-_.]?(\d{2})[-_.]?(\d{2})(?:[ _T-]?(\d{2})[-_.:]?(\d{2})(?:[-_.:]?(\d{2}))?)?`)
+
+// ImportItem 待导入的压缩包或文件夹
+type ImportItem struct {
+	Path       string
+	Time       time.Time
+	TimeSource string // 时间的来源 文件名 / level.dat / 文件修改时间
+}
+
+// importEntry 压缩包或文件夹中的一个文件
+type importEntry struct {
+	name     string // 相对路径 以 / 分隔
+	modified time.Time
+	open     func() (io.ReadCloser, error)
+}
+
+// PlanImport 读取每个压缩包或文件夹的时间 按时间先后排序
+func PlanImport(a *database.Archive, paths []string) ([]ImportItem, error) {
+	var items = make([]ImportItem, 0, len(paths))
+	for _, p := range paths {
+		entries, closer, err := readImportEntries(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(p), err)
+		}
+		if _, _, err = importLayout(a, entries); err != nil {
+			closer()
+			return nil, fmt.Errorf("%s: %w", filepath.Base(p), err)
+		}
+
+		var item = ImportItem{Path: p}
+		item.Time, item.TimeSource = importTime(p, entries)
+		closer()
+		if item.Time.IsZero() {
+			return nil, fmt.Errorf("%s: 无法确定备份时间", filepath.Base(p))
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Time.Before(items[j].Time)
+	})
+	return items, nil
+}
+
+// ImportBackups 按时间先后将压缩包或文件夹备份到 restic 快照时间与备份记录均使用原来的时间
+// 内容先解压或复制到暂存目录 文件夹名称与存档相同 存档本身不会被修改
+// 有多个路径的存档只导入主路径 出错时停止 已导入的记录会保留
+func ImportBackups(a *database.Archive, items []ImportItem) <-chan *BackupMessage {
+	outputChan := make(chan *BackupMessage, 100)
+
+	go func() {
+		defer close(outputChan)
+		defer os.RemoveAll(importDir(a))
+
+		repoMu.RLock()
+		defer repoMu.RUnlock()
+
+		var fail = func(item ImportItem, err error) {
+			outputChan <- &BackupMessage{MessageType: "error", Message: fmt.Sprintf("%s: %v", filepath.Base(item.Path), err), Code: 1}
+		}
+
+		var parent string
+		var imported, skipped int
+		for i, item := range items {
+			outputChan <- &BackupMessage{
+				MessageType: "info",
+				PercentDone: float64(i) / float64(len(items)),
+				Message:     fmt.Sprintf("(%d/%d) %s", i+1, len(items), filepath.Base(item.Path)),
+			}
+
+			record, err := importItem(a, item, parent)
+			if err != nil {
+				fail(item, err)
+				return
+			}
+			if record == nil {
+				skipped++
+				continue
+			}
+			parent = record.SnapShot
+			imported++
+		}
+
+		var message = fmt.Sprintf("已导入 %d 个备份", imported)
+		if skipped > 0 {
+			message += fmt.Sprintf("，%d 个与上一个备份完全相同，已跳过", skipped)
+		}
+		outputChan <- &BackupMessage{MessageType: "done", Message: message, PercentDone: 1}
+	}()
+
+	return outputChan
+}
+
+// importDir 导入时的暂存目录
+func importDir(a *database.Archive) string {
+	return filepath.Join(etc.DataDir, "import", strconv.FormatUint(uint64(a.ID), 10))
+}
+
+// importItem 导入一个备份 与上一个备份完全相同时 restic 不会创建快照 返回 nil
+func importItem(a *database.Archive, item ImportItem, parent string) (*database.BackupRecord, error) {
+	entries, closer, err := readImportEntries(item.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	var root = filepath.Join(importDir(a), filepath.Base(a.Path))
+	if err := os.RemoveAll(root); err != nil {
+		return nil, fmt.Errorf("清理暂存目录失败: %w", err)
+	}
+	defer os.RemoveAll(root)
+
+	worldPath, err := stageImport(a, entries, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary *BackupMessage
+	var backupErr error
+	for msg := range resticBackupAt(a, []string{root}, parent, item.Time) {
+		switch {
+		case msg.MessageType == "summary":
+			summary = msg
+		case backupErr == nil && (msg.Code != 0 || (msg.MessageType == "error" && msg.Message != "")):
+			backupErr = errors.New(msg.Message)
+		}
+	}
+	switch {
+	case backupErr != nil:
+		return nil, backupErr
+	case summary == nil:
+		return nil, errors.New("备份没有返回摘要信息")
+	case summary.Skipped():
+		return nil, nil
+	}
+
+	var record = &database.BackupRecord{
+		CreatedAt: item.Time,
+		ArchiveID: a.ID,
+		SnapShot:  summary.SnapshotID,
+		Comment:   fmt.Sprintf("%s %s", ImportComment, filepath.Base(item.Path)),
+		Parent:    parent,
+		DataAdded: summary.DataAdded,
+		Duration:  summary.TotalDuration,
+	}
+	if worldPath != "" {
+		if level, err := world.ReadLevel(worldPath); err == nil {
+			record.DataVersion, record.VersionName = level.DataVersion, level.VersionName
+		}
+	}
+	if err := CreateBackupRecord(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// stageImport 将内容解压或复制到 target 返回其中世界的路径 没有世界的类型返回空字符串
+func stageImport(a *database.Archive, entries []importEntry, target string) (string, error) {
+	prefix, sub, err := importLayout(a, entries)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		var rel = entry.name
+		if prefix != "" {
+			var ok bool
+			if rel, ok = strings.CutPrefix(entry.name, prefix+"/"); !ok {
+				continue
+			}
+		}
+		// 防止压缩包中的路径跳出暂存目录
+		if !filepath.IsLocal(filepath.FromSlash(rel)) || path.Base(rel) == "session.lock" {
+			continue
+		}
+
+		var dst = filepath.Join(target, sub, filepath.FromSlash(rel))
+		if err := extractEntry(entry, dst); err != nil {
+			return "", fmt.Errorf("解压 %s 失败: %w", rel, err)
+		}
+	}
+
+	switch NormalizeArchiveType(a.Type) {
+	case database.ArchiveTypeWorld, database.ArchiveTypeBedrock:
+		return target, nil
+	case database.ArchiveTypeServer:
+		if sub != "" {
+			return filepath.Join(target, sub), nil
+		}
+		return filepath.Join(target, world.ServerLevelName(target)), nil
+	}
+	return "", nil
+}
+
+// extractEntry 写入一个文件 保留原来的修改时间
+func extractEntry(entry importEntry, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	reader, err := entry.open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, reader)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || entry.modified.IsZero() {
+		return err
+	}
+	return os.Chtimes(dst, entry.modified, entry.modified)
+}
+
+// importLayout 找到内容中与存档根目录对应的部分
+// prefix 为对应存档根目录的路径 sub 为写入存档中的子目录 (服务器只有世界时为世界文件夹名称)
+func importLayout(a *database.Archive, entries []importEntry) (prefix, sub string, err error) {
+	if len(entries) == 0 {
+		return "", "", errors.New("没有任何文件")
+	}
+
+	var levelDat, properties = shallowest(entries, world.LevelDatName), shallowest(entries, world.ServerPropertiesName)
+	switch NormalizeArchiveType(a.Type) {
+	case database.ArchiveTypeWorld, database.ArchiveTypeBedrock:
+		if levelDat == "" {
+			return "", "", errors.New("没有找到 level.dat")
+		}
+		return parentDir(levelDat), "", nil
+	case database.ArchiveTypeServer:
+		if properties != "" {
+			return parentDir(properties), "", nil
+		}
+		if levelDat == "" {
+			return "", "", errors.New("没有找到 server.properties 或 level.dat")
+		}
+		return parentDir(levelDat), world.ServerLevelName(a.Path), nil
+	}
+
+	// 实例与文件夹 所有文件都在同一个文件夹中时以该文件夹为根目录
+	var top, _, _ = strings.Cut(entries[0].name, "/")
+	for _, entry := range entries {
+		if first, _, found := strings.Cut(entry.name, "/"); !found || first != top {
+			return "", "", nil
+		}
+	}
+	return top, "", nil
+}
+
+// shallowest 层级最浅的同名文件 没有时返回空字符串
+func shallowest(entries []importEntry, name string) string {
+	var found string
+	for _, entry := range entries {
+		if path.Base(entry.name) != name {
+			continue
+		}
+		if found == "" || strings.Count(entry.name, "/") < strings.Count(found, "/") {
+			found = entry.name
+		}
+	}
+	return found
+}
+
+// parentDir 文件所在的文件夹 根目录为空字符串
+func parentDir(name string) string {
+	if dir := path.Dir(name); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+// importTime 依次从文件名、level.dat 的 LastPlayed 与最新的文件修改时间中读取备份时间
+// LastPlayed 在游戏保存时写入 比压缩工具记录的修改时间可靠
+func importTime(p string, entries []importEntry) (time.Time, string) {
+	if t, ok := parseImportTime(filepath.Base(p)); ok {
+		return t, "文件名"
+	}
+
+	if levelDat := shallowest(entries, world.LevelDatName); levelDat != "" {
+		for _, entry := range entries {
+			if entry.name == levelDat {
+				if t := lastPlayed(entry); !t.IsZero() {
+					return t, "level.dat"
+				}
+				break
+			}
+		}
+	}
+
+	var latest time.Time
+	for _, entry := range entries {
+		if entry.modified.After(latest) {
+			latest = entry.modified
+		}
+	}
+	return latest, "文件修改时间"
+}
+
+// parseImportTime 解析文件名中的日期与时间 只有日期时为当天零点
+func parseImportTime(name string) (time.Time, bool) {
+	var match = importTimePattern.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}, false
+	}
+
+	var values [6]int
+	for i := range values {
+		values[i], _ = strconv.Atoi(match[i+1])
+	}
+	var t = time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], 0, time.Local)
+	// 超出范围的数值会被 time.Date 进位 视为不是时间
+	if t.Month() != time.Month(values[1]) || t.Day() != values[2] || t.Hour() != values[3] ||
+		t.Minute() != values[4] || t.Second() != values[5] || t.After(time.Now()) {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// lastPlayed 读取 level.dat 中最后游玩的时间 基岩版或读取失败时为零值
+func lastPlayed(entry importEntry) time.Time {
+	reader, err := entry.open()
+	if err != nil {
+		return time.Time{}
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return time.Time{}
+	}
+	root, err := nbt.DecodeBytes(data)
+	if err != nil {
+		return time.Time{}
+	}
+	level, err := world.ParseLevel(root)
+	if err != nil {
+		return time.Time{}
+	}
+	return level.LastPlayed
+}
+
+// readImportEntries 列出压缩包或文件夹中的所有文件 使用完后调用 closer
+func readImportEntries(p string) ([]importEntry, func(), error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.IsDir() {
+		var entries []importEntry
+		err := filepath.WalkDir(p, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(p, file)
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			entries = append(entries, importEntry{
+				name:     filepath.ToSlash(rel),
+				modified: info.ModTime(),
+				open:     func() (io.ReadCloser, error) { return os.Open(file) },
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取文件夹失败: %w", err)
+		}
+		return entries, func() {}, nil
+	}
+
+	reader, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无法打开压缩包: %w", err)
+	}
+	var entries []importEntry
+	for _, f := range reader.File {
+		// 跳过文件夹与 macOS 压缩时附带的元数据
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		entries = append(entries, importEntry{
+			name:     strings.TrimPrefix(path.Clean(strings.ReplaceAll(f.Name, `\`, "/")), "/"),
+			modified: zipModified(f),
+			open:     f.Open,
+		})
+	}
+	return entries, func() { _ = reader.Close() }, nil
+}
+
+// zipModified 压缩包中文件的修改时间
+// 没有扩展时间戳时 zip 只记录不带时区的本地时间 Go 将其视为 UTC 需要按本地时间重新解释
+func zipModified(f *zip.File) time.Time {
+	var t = f.Modified
+	// 没有记录时间时 MS-DOS 时间为 0 即 1979 年
+	if t.Year() < 1980 {
+		return time.Time{}
+	}
+	if t.Location() != time.UTC {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+}
@@ -2,10 +2,14 @@ package archive
 
 import (
 	"errors"
+	"fmt"
 	"gorm.io/gorm"
 	"minecraft-archive-backup/model/dto/database"
 )
 
+// ErrSnapshotPinned 快照已固定 需要先取消固定才能删除
+var ErrSnapshotPinned = errors.New("快照已固定，请先取消固定")
+
 // CreateBackupRecord 创建一个备份记录
 func CreateBackupRecord(backupRecord *database.BackupRecord) error {
 	// 检查关联的存档是否存在
@@ -45,6 +49,100 @@ func GetBackupRecordsByArchiveID(archiveID uint) ([]database.BackupRecord, error
 	return backupRecords, result.Error
 }
 
+// SetBackupRecordPinned 固定或取消固定一个备份记录 并同步 restic 中的 pinned 标签
+func SetBackupRecordPinned(backupRecord *database.BackupRecord, pinned bool) error {
+	if backupRecord.Pinned == pinned {
+		return nil
+	}
+
+	// 修改标签与更新记录之间不允许迁移仓库
+	repoMu.RLock()
+	defer repoMu.RUnlock()
+
+	// restic 修改标签会产生新的快照ID
+	newID, err := ResticTag(backupRecord.SnapShot, pinned, PinnedTag)
+	if err != nil {
+		return err
+	}
+
+	var oldID = backupRecord.SnapShot
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&database.BackupRecord{}).Where("id = ?", backupRecord.ID).
+			Updates(map[string]any{"snap_shot": newID, "pinned": pinned})
+		if result.Error != nil {
+			return fmt.Errorf("更新备份记录失败: %w", result.Error)
+		}
+
+		// 以该快照为父快照的记录 同步修改父快照ID
+		result = tx.Model(&database.BackupRecord{}).Where("parent = ?", oldID).Update("parent", newID)
+		if result.Error != nil {
+			return fmt.Errorf("更新父快照失败: %w", result.Error)
+		}
+
+		// 预览图按快照ID缓存 随快照一起改名
+		renameMapPreviews(oldID, newID)
+
+		backupRecord.SnapShot = newID
+		backupRecord.Pinned = pinned
+		return nil
+	})
+}
+
+// CountPinnedBackupRecords 统计指定存档下已固定的备份记录数量
+func CountPinnedBackupRecords(archiveID uint) (int64, error) {
+	var count int64
+	result := DB.Model(&database.BackupRecord{}).Where("archive_id = ? AND pinned = ?", archiveID, true).Count(&count)
+	return count, result.Error
+}
+
+// plainCompareCount 比较规范化效果时参考的直接备份数量
+const plainCompareCount = 10
+
+// AveragePlainDataAdded 指定存档最近 limit 次直接备份的平均新增数据量
+// 只统计父快照同样是直接备份的记录 以规范化快照为父快照时几乎所有区域文件都会变化 不具有可比性
+func AveragePlainDataAdded(archiveID uint, limit int) (average int64, count int, err error) {
+	var records []database.BackupRecord
+	result := DB.Where("archive_id = ? AND normalized = ? AND parent IN (?)", archiveID, false,
+		DB.Model(&database.BackupRecord{}).Select("snap_shot").Where("archive_id = ? AND normalized = ?", archiveID, false)).
+		Order("created_at DESC").Limit(limit).Find(&records)
+	if result.Error != nil {
+		return 0, 0, result.Error
+	}
+	if len(records) == 0 {
+		return 0, 0, nil
+	}
+
+	var total int64
+	for _, r := range records {
+		total += r.DataAdded
+	}
+	return total / int64(len(records)), len(records), nil
+}
+
+// GetLatestBackupRecordByArchiveID 查询指定存档最近一次成功的备份记录 没有记录时返回 nil
+func GetLatestBackupRecordByArchiveID(archiveID uint) (*database.BackupRecord, error) {
+	var backupRecord database.BackupRecord
+	result := DB.Where("archive_id = ?", archiveID).Order("created_at DESC").First(&backupRecord)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &backupRecord, result.Error
+}
+
+// GetBackupRecordBySnapShot 通过快照ID查询备份记录 没有记录时返回 nil
+func GetBackupRecordBySnapShot(snapShot string) (*database.BackupRecord, error) {
+	if snapShot == "" {
+		return nil, nil
+	}
+
+	var backupRecord database.BackupRecord
+	result := DB.Where("snap_shot = ?", snapShot).First(&backupRecord)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &backupRecord, result.Error
+}
+
 // GetBackupRecordByID 通过备份记录ID查询指定备份记录
 func GetBackupRecordByID(backupID uint) (*database.BackupRecord, error) {
 	var backupRecord database.BackupRecord
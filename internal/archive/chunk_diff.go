@@ -0,0 +1,330 @@
+package archive
+
+import (
+	"fmt"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	"minecraft-archive-backup/pkg/region"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 同时读取区域文件头的 restic 进程数
+const dumpWorkers = 4
+
+// ChunkChangeKind 区块的变化类型
+type ChunkChangeKind int
+
+const (
+	ChunkCreated  ChunkChangeKind = iota // 新生成的区块
+	ChunkModified                        // 重新保存过的区块
+	ChunkDeleted                         // 被删除的区块
+)
+
+func (k ChunkChangeKind) String() string {
+	switch k {
+	case ChunkCreated:
+		return "新增"
+	case ChunkModified:
+		return "修改"
+	case ChunkDeleted:
+		return "删除"
+	}
+	return "未知"
+}
+
+// ChunkChange 单个区块的变化
+type ChunkChange struct {
+	Dimension string // region 文件夹的上级路径 名称见 world.DimensionName
+	Region    string // 区域文件名
+	X, Z      int    // 区块坐标
+	Kind      ChunkChangeKind
+	OldTime   time.Time // 旧快照中区块的保存时间
+	NewTime   time.Time // 新快照中区块的保存时间
+}
+
+// BlockRange 区块覆盖的方块坐标范围
+func (c ChunkChange) BlockRange() (minX, minZ, maxX, maxZ int) {
+	return c.X * 16, c.Z * 16, c.X*16 + 15, c.Z*16 + 15
+}
+
+// RegionSummary 单个区域文件的变化统计
+type RegionSummary struct {
+	Dimension string
+	Region    string
+	Created   int
+	Modified  int
+	Deleted   int
+}
+
+// ChunkDiff 两个快照之间的区块变化
+type ChunkDiff struct {
+	Changes []ChunkChange
+	Regions []RegionSummary
+}
+
+// SnapshotRegion 快照中的区域文件
+type SnapshotRegion struct {
+	Dimension    string
+	Folder       string // region / entities / poi
+	Name         string
+	X, Z         int
+	SnapshotPath string // 快照中的完整路径 用于 restic dump
+	Size         int64
+	MTime        time.Time
+}
+
+// key 同一个区域文件在不同快照中的标识
+func (r *SnapshotRegion) key() string {
+	return path.Join(r.Dimension, r.Folder, r.Name)
+}
+
+// DiffChunks 比较两个快照中的区域文件 得到每个维度中新增、修改与删除的区块
+// 大小与修改时间都相同的区域文件不会读取 其余的只读取文件头中的位置表与时间戳表
+func DiffChunks(from, to *database.BackupRecord) (*ChunkDiff, error) {
+	oldRegions, err := SnapshotRegions(from.SnapShot, world.RegionDir)
+	if err != nil {
+		return nil, err
+	}
+	newRegions, err := SnapshotRegions(to.SnapShot, world.RegionDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// 需要比较的区域文件 大小与修改时间都相同的直接跳过
+	var pairs [][2]*SnapshotRegion
+	for key, r := range newRegions {
+		var old = oldRegions[key]
+		if old != nil && old.Size == r.Size && old.MTime.Equal(r.MTime) {
+			continue
+		}
+		pairs = append(pairs, [2]*SnapshotRegion{old, r})
+	}
+	for key, r := range oldRegions {
+		if newRegions[key] == nil {
+			pairs = append(pairs, [2]*SnapshotRegion{r, nil})
+		}
+	}
+
+	var (
+		diff     = &ChunkDiff{}
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		sem      = make(chan struct{}, dumpWorkers)
+	)
+	for _, pair := range pairs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			changes, summary, err := diffRegion(from.SnapShot, to.SnapShot, pair[0], pair[1])
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			if len(changes) > 0 {
+				diff.Changes = append(diff.Changes, changes...)
+				diff.Regions = append(diff.Regions, summary)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		var a, b = diff.Changes[i], diff.Changes[j]
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		return a.X < b.X
+	})
+	sort.Slice(diff.Regions, func(i, j int) bool {
+		var a, b = diff.Regions[i], diff.Regions[j]
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+		return a.Region < b.Region
+	})
+
+	return diff, nil
+}
+
+// diffRegion 比较同一个区域文件在两个快照中的文件头 old 与 new 可以有一个为 nil
+func diffRegion(fromSnapshot, toSnapshot string, old, new *SnapshotRegion) ([]ChunkChange, RegionSummary, error) {
+	oldHeader, err := regionHeader(fromSnapshot, old)
+	if err != nil {
+		return nil, RegionSummary{}, err
+	}
+	newHeader, err := regionHeader(toSnapshot, new)
+	if err != nil {
+		return nil, RegionSummary{}, err
+	}
+
+	var info = new
+	if info == nil {
+		info = old
+	}
+	changes, summary := compareHeaders(info, oldHeader, newHeader)
+	return changes, summary, nil
+}
+
+// compareHeaders 逐个比较两个文件头中的区块
+func compareHeaders(info *SnapshotRegion, oldHeader, newHeader *region.Header) ([]ChunkChange, RegionSummary) {
+	var changes []ChunkChange
+	var summary = RegionSummary{Dimension: info.Dimension, Region: info.Name}
+
+	for i := 0; i < region.ChunksPerRegion; i++ {
+		var oldExists = oldHeader.Locations[i].Exists()
+		var newExists = newHeader.Locations[i].Exists()
+
+		var change = ChunkChange{
+			Dimension: info.Dimension,
+			Region:    info.Name,
+			OldTime:   oldHeader.Time(i),
+			NewTime:   newHeader.Time(i),
+		}
+		change.X, change.Z = region.ChunkPos(info.X, info.Z, i)
+
+		switch {
+		case !oldExists && newExists:
+			change.Kind = ChunkCreated
+			summary.Created++
+		case oldExists && !newExists:
+			change.Kind = ChunkDeleted
+			summary.Deleted++
+		case oldExists && newExists && oldHeader.Timestamps[i] != newHeader.Timestamps[i]:
+			change.Kind = ChunkModified
+			summary.Modified++
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, summary
+}
+
+// regionHeader 读取快照中区域文件的文件头 文件不存在时返回空的文件头
+func regionHeader(snapshot string, r *SnapshotRegion) (*region.Header, error) {
+	if r == nil || r.Size == 0 {
+		return &region.Header{}, nil
+	}
+
+	data, err := ResticDumpHead(snapshot, r.SnapshotPath, region.HeaderSize)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := region.ParseHeader(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r.SnapshotPath, err)
+	}
+	return header, nil
+}
+
+// SnapshotRegions 列出快照中指定文件夹 (例如 region) 中所有的 .mca 文件 键为 维度/文件夹/文件名
+func SnapshotRegions(snapshot string, folders ...string) (map[string]*SnapshotRegion, error) {
+	roots, nodes, err := listSnapshot(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return regionsFromNodes(roots, nodes, folders...), nil
+}
+
+// listSnapshot 快照的备份路径 (以 / 分隔) 与其中所有的文件
+func listSnapshot(snapshot string) ([]string, []*LsNode, error) {
+	info, err := ResticSnapshotInfo(snapshot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询快照信息失败: %w", err)
+	}
+
+	nodes, err := ResticLs(snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var roots = make([]string, 0, len(info.Paths))
+	for _, p := range info.Paths {
+		roots = append(roots, strings.TrimSuffix(ConvertWindowsToUnixPath(p), "/"))
+	}
+	return roots, nodes, nil
+}
+
+// regionsFromNodes 从快照的文件列表中筛选出指定文件夹中的 .mca 文件
+func regionsFromNodes(roots []string, nodes []*LsNode, folders ...string) map[string]*SnapshotRegion {
+	var regions = make(map[string]*SnapshotRegion)
+	for _, node := range nodes {
+		if node.Type != "file" {
+			continue
+		}
+
+		var rel = snapshotRelPath(roots, node.Path)
+		if rel == "" {
+			continue
+		}
+
+		dir, name := path.Split(rel)
+		dir = strings.TrimSuffix(dir, "/")
+		var folder = path.Base(dir)
+		if !slices.Contains(folders, folder) {
+			continue
+		}
+		x, z, ok := region.ParseFileName(name)
+		if !ok {
+			continue
+		}
+
+		var dimension = path.Dir(dir)
+		if dimension == "." {
+			dimension = ""
+		}
+
+		var r = &SnapshotRegion{
+			Dimension:    dimension,
+			Folder:       folder,
+			Name:         name,
+			X:            x,
+			Z:            z,
+			SnapshotPath: node.Path,
+			Size:         node.Size,
+			MTime:        node.MTime,
+		}
+		regions[r.key()] = r
+	}
+
+	return regions
+}
+
+// snapshotRelPath 快照中的路径相对于备份路径的部分 不属于任何备份路径时返回空字符串
+// 存档有多个路径时 以备份路径的文件夹名称开头 例如 world_nether/DIM-1/region/r.0.0.mca
+func snapshotRelPath(roots []string, p string) string {
+	for _, root := range roots {
+		if !strings.HasPrefix(p, root+"/") {
+			continue
+		}
+		var rel = strings.TrimPrefix(p, root+"/")
+		if len(roots) > 1 {
+			rel = path.Base(root) + "/" + rel
+		}
+		return rel
+	}
+	return ""
+}
@@ -0,0 +1,341 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	"minecraft-archive-backup/pkg/nbt"
+	"minecraft-archive-backup/pkg/region"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TicksPerMinute 游戏中一分钟的刻数 InhabitedTime 以刻为单位
+const TicksPerMinute = 20 * 60
+
+// BlockRect 方块坐标矩形 用于保护区域
+type BlockRect struct {
+	X1, Z1, X2, Z2 int
+}
+
+// containsChunk 矩形是否与区块有重叠
+func (r BlockRect) containsChunk(chunkX, chunkZ int) bool {
+	return chunkX >= min(r.X1, r.X2)>>4 && chunkX <= max(r.X1, r.X2)>>4 &&
+		chunkZ >= min(r.Z1, r.Z2)>>4 && chunkZ <= max(r.Z1, r.Z2)>>4
+}
+
+// PruneOptions 清理区块的条件
+type PruneOptions struct {
+	MinInhabited int64       // InhabitedTime 低于该值 (刻) 的区块会被删除
+	Protected    []BlockRect // 保护区域 所有维度通用
+}
+
+// PruneDimension 单个维度的清理统计
+type PruneDimension struct {
+	Dimension string // region 文件夹的上级路径 名称见 world.DimensionName
+	Chunks    int    // 删除的区块数
+	Kept      int    // 保留的区块数
+	Bytes     int64  // 节省的空间 (含 entities 与 poi)
+}
+
+// PruneReport 清理区块的统计
+type PruneReport struct {
+	Dimensions []PruneDimension
+	Chunks     int
+	Bytes      int64
+	plan       map[string][]int // 区域文件路径 -> 需要删除的区块索引
+}
+
+// liveRegion 存档中的区域文件
+type liveRegion struct {
+	dimension string
+	dir       string // region 文件夹的上级目录
+	name      string
+}
+
+// pruneFolders 删除区块时一并删除的文件夹 保证方块、实体与兴趣点一致
+var pruneFolders = []string{world.RegionDir, world.EntitiesDir, world.PoiDir}
+
+// ParseProtectedAreas 解析保护区域 每行一个矩形 格式为 x1,z1,x2,z2 (方块坐标)
+func ParseProtectedAreas(text string) ([]BlockRect, error) {
+	var rects []BlockRect
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var fields = strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == '，' || r == ' ' || r == '\t'
+		})
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("第 %d 行格式有误，应为 x1,z1,x2,z2", i+1)
+		}
+		var values [4]int
+		for j, field := range fields {
+			value, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行的坐标必须是整数", i+1)
+			}
+			values[j] = value
+		}
+		rects = append(rects, BlockRect{values[0], values[1], values[2], values[3]})
+	}
+	return rects, nil
+}
+
+// PlanPrune 统计会被删除的区块 不修改存档
+func PlanPrune(a *database.Archive, options PruneOptions) (*PruneReport, error) {
+	if t := NormalizeArchiveType(a.Type); t != database.ArchiveTypeWorld && t != database.ArchiveTypeServer {
+		return nil, errors.New("只有 Java 版的世界与服务器可以清理区块")
+	}
+
+	regions, err := liveRegions(a)
+	if err != nil {
+		return nil, err
+	}
+
+	var report = &PruneReport{plan: make(map[string][]int)}
+	var dimensions = make(map[string]*PruneDimension)
+	for _, r := range regions {
+		var dimension = dimensions[r.dimension]
+		if dimension == nil {
+			dimension = &PruneDimension{Dimension: r.dimension}
+			dimensions[r.dimension] = dimension
+		}
+
+		indexes, bytes, kept, err := planRegion(r, options)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+		dimension.Kept += kept
+		if len(indexes) == 0 {
+			continue
+		}
+
+		report.plan[filepath.Join(r.dir, world.RegionDir, r.name)] = indexes
+		dimension.Chunks += len(indexes)
+		dimension.Bytes += bytes
+		report.Chunks += len(indexes)
+		report.Bytes += bytes
+	}
+
+	for _, dimension := range dimensions {
+		report.Dimensions = append(report.Dimensions, *dimension)
+	}
+	sort.Slice(report.Dimensions, func(i, j int) bool {
+		return report.Dimensions[i].Dimension < report.Dimensions[j].Dimension
+	})
+	return report, nil
+}
+
+// planRegion 找出区域中需要删除的区块 返回索引、节省的字节数与保留的区块数
+func planRegion(r liveRegion, options PruneOptions) ([]int, int64, int, error) {
+	regionX, regionZ, _ := region.ParseFileName(r.name)
+
+	file, err := region.ReadFile(filepath.Join(r.dir, world.RegionDir, r.name))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var indexes []int
+	var kept int
+	for i := 0; i < region.ChunksPerRegion; i++ {
+		if !file.Has(i) {
+			continue
+		}
+		if !pruneChunk(file, i, regionX, regionZ, options) {
+			kept++
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	if len(indexes) == 0 {
+		return nil, 0, kept, nil
+	}
+
+	// 同一位置的实体与兴趣点数据也会删除
+	var bytes int64
+	for _, folder := range pruneFolders {
+		var other = file
+		if folder != world.RegionDir {
+			if other, err = region.ReadFile(filepath.Join(r.dir, folder, r.name)); err != nil {
+				return nil, 0, 0, err
+			}
+		}
+		for _, index := range indexes {
+			bytes += int64((len(other.Chunk(index)) + region.SectorSize - 1) / region.SectorSize * region.SectorSize)
+		}
+	}
+	return indexes, bytes, kept, nil
+}
+
+// pruneChunk 区块是否满足删除条件 无法读取的区块一律保留
+func pruneChunk(file *region.File, index, regionX, regionZ int, options PruneOptions) bool {
+	chunkX, chunkZ := region.ChunkPos(regionX, regionZ, index)
+	for _, rect := range options.Protected {
+		if rect.containsChunk(chunkX, chunkZ) {
+			return false
+		}
+	}
+
+	var payload = file.Payload(index)
+	if payload == nil {
+		return false
+	}
+	root, err := nbt.DecodeBytes(payload)
+	if err != nil {
+		return false
+	}
+	return world.InhabitedTime(root) < options.MinInhabited
+}
+
+// PruneChunks 删除玩家几乎没有停留过的区块 游戏下次经过时会重新生成
+// 存档正在被使用时拒绝执行 删除之前会先创建一个安全备份
+func PruneChunks(a *database.Archive, options PruneOptions) <-chan *BackupMessage {
+	outputChan := make(chan *BackupMessage, 100)
+
+	go func() {
+		defer close(outputChan)
+
+		var fail = func(err error) {
+			outputChan <- &BackupMessage{MessageType: "error", Message: err.Error(), Code: 1}
+		}
+
+		if err := CheckArchiveNotInUse(a); err != nil {
+			fail(err)
+			return
+		}
+
+		// 1. 安全备份 误删的区块可以通过区块回档找回
+		outputChan <- &BackupMessage{MessageType: "info", Message: "正在创建安全备份..."}
+		if _, err := RunBackup(a, "清理区块前的自动备份"); err != nil {
+			fail(fmt.Errorf("安全备份失败，已取消清理: %w", err))
+			return
+		}
+
+		// 写入前再次确认 避免安全备份期间进入了存档
+		if err := CheckArchiveNotInUse(a); err != nil {
+			fail(err)
+			return
+		}
+
+		// 2. 按最新的存档重新统计
+		outputChan <- &BackupMessage{MessageType: "info", Message: "正在统计需要删除的区块..."}
+		report, err := PlanPrune(a, options)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		// 3. 逐个区域文件删除区块
+		var files = make([]string, 0, len(report.plan))
+		for file := range report.plan {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+
+		for i, file := range files {
+			outputChan <- &BackupMessage{
+				MessageType: "info",
+				PercentDone: float64(i+1) / float64(len(files)),
+				Message:     filepath.Base(file),
+			}
+
+			var dir = filepath.Dir(filepath.Dir(file))
+			for _, folder := range pruneFolders {
+				if err := pruneRegion(filepath.Join(dir, folder, filepath.Base(file)), report.plan[file]); err != nil {
+					fail(fmt.Errorf("%s/%s: %w", folder, filepath.Base(file), err))
+					return
+				}
+			}
+		}
+
+		outputChan <- &BackupMessage{
+			MessageType: "done",
+			Message:     fmt.Sprintf("已删除 %d 个区块，节省 %.2f MB", report.Chunks, float64(report.Bytes)/1048576),
+			PercentDone: 1,
+		}
+	}()
+
+	return outputChan
+}
+
+// pruneRegion 删除区域文件中的区块 所有区块都被删除时删除整个文件
+func pruneRegion(regionPath string, indexes []int) error {
+	file, err := region.ReadFile(regionPath)
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	for _, index := range indexes {
+		if !file.Has(index) {
+			continue
+		}
+		// 过大的区块存放在单独的文件中
+		if file.IsExternal(index) {
+			regionX, regionZ, _ := region.ParseFileName(filepath.Base(regionPath))
+			chunkX, chunkZ := region.ChunkPos(regionX, regionZ, index)
+			var external = filepath.Join(filepath.Dir(regionPath), region.ExternalFileName(chunkX, chunkZ))
+			if err := os.Remove(external); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		file.RemoveChunk(index)
+		changed = true
+	}
+
+	switch {
+	case !changed:
+		return nil
+	case file.Len() == 0:
+		return os.Remove(regionPath)
+	}
+	return region.WriteFile(regionPath, file)
+}
+
+// liveRegions 存档中所有维度的区域文件 维度的表示方式与快照中相同
+func liveRegions(a *database.Archive) ([]liveRegion, error) {
+	var roots = a.BackupPaths()
+	var regions []liveRegion
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || filepath.Base(filepath.Dir(p)) != world.RegionDir {
+				return nil
+			}
+			if _, _, ok := region.ParseFileName(d.Name()); !ok {
+				return nil
+			}
+
+			var dir = filepath.Dir(filepath.Dir(p))
+			rel, err := filepath.Rel(root, dir)
+			if err != nil {
+				return err
+			}
+			var dimension = filepath.ToSlash(rel)
+			if dimension == "." {
+				dimension = ""
+			}
+			if len(roots) > 1 {
+				dimension = path.Join(filepath.Base(root), dimension)
+			}
+
+			regions = append(regions, liveRegion{dimension: dimension, dir: dir, name: d.Name()})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("读取区域文件失败: %w", err)
+		}
+	}
+	return regions, nil
+}
@@ -0,0 +1,344 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	"minecraft-archive-backup/pkg/region"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChunkPos 区块坐标
+type ChunkPos struct {
+	X, Z int
+}
+
+// rollbackFolders 回档区块时一并恢复的文件夹 保证方块、实体与兴趣点一致
+var rollbackFolders = []string{world.RegionDir, world.EntitiesDir, world.PoiDir}
+
+// MaxRollbackChunks 一次回档的区块数量上限 (相当于 16 个区域文件) 避免坐标输错时规划过多的区块
+const MaxRollbackChunks = 16 * region.ChunksPerRegion
+
+// ChunksInRect 方块坐标矩形覆盖的所有区块 两个角的顺序不限
+func ChunksInRect(x1, z1, x2, z2 int) ([]ChunkPos, error) {
+	var minX, maxX = min(x1, x2) >> 4, max(x1, x2) >> 4
+	var minZ, maxZ = min(z1, z2) >> 4, max(z1, z2) >> 4
+
+	var width, depth = int64(maxX-minX) + 1, int64(maxZ-minZ) + 1
+	if width*depth > MaxRollbackChunks {
+		return nil, fmt.Errorf("所选范围包含 %d 个区块，超过上限 %d，请检查坐标", width*depth, MaxRollbackChunks)
+	}
+
+	var chunks = make([]ChunkPos, 0, (maxX-minX+1)*(maxZ-minZ+1))
+	for z := minZ; z <= maxZ; z++ {
+		for x := minX; x <= maxX; x++ {
+			chunks = append(chunks, ChunkPos{X: x, Z: z})
+		}
+	}
+	return chunks, nil
+}
+
+// ParseChunkList 解析区块列表 每行一个 "x,z" 或 "x z" 忽略空行与重复的区块
+func ParseChunkList(text string) ([]ChunkPos, error) {
+	var chunks []ChunkPos
+	var seen = make(map[ChunkPos]bool)
+
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var fields = strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == '，' || r == ' ' || r == '\t'
+		})
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("第 %d 行格式有误，应为 x,z", i+1)
+		}
+		x, errX := strconv.Atoi(fields[0])
+		z, errZ := strconv.Atoi(fields[1])
+		if errX != nil || errZ != nil {
+			return nil, fmt.Errorf("第 %d 行的坐标不是整数", i+1)
+		}
+
+		var pos = ChunkPos{X: x, Z: z}
+		if !seen[pos] {
+			seen[pos] = true
+			chunks = append(chunks, pos)
+		}
+	}
+	if len(chunks) > MaxRollbackChunks {
+		return nil, fmt.Errorf("区块数量 %d 超过上限 %d", len(chunks), MaxRollbackChunks)
+	}
+	return chunks, nil
+}
+
+// SnapshotDimensions 快照中包含区块数据的维度 (region 文件夹的上级路径)
+func SnapshotDimensions(snapshot string) ([]string, error) {
+	regions, err := SnapshotRegions(snapshot, world.RegionDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var seen = make(map[string]bool)
+	var dimensions []string
+	for _, r := range regions {
+		if !seen[r.Dimension] {
+			seen[r.Dimension] = true
+			dimensions = append(dimensions, r.Dimension)
+		}
+	}
+	sort.Strings(dimensions)
+	return dimensions, nil
+}
+
+// RollbackChunks 将快照中指定维度的部分区块写回到存档中 其他区块保持不变
+// 存档正在被使用时拒绝执行 写入之前会先创建一个安全备份
+func RollbackChunks(a *database.Archive, record *database.BackupRecord, dimension string, chunks []ChunkPos) <-chan *BackupMessage {
+	outputChan := make(chan *BackupMessage, 100)
+
+	go func() {
+		defer close(outputChan)
+
+		var fail = func(err error) {
+			outputChan <- &BackupMessage{MessageType: "error", Message: err.Error(), Code: 1}
+		}
+
+		if len(chunks) == 0 {
+			fail(errors.New("没有选择需要回档的区块"))
+			return
+		}
+		if err := CheckArchiveNotInUse(a); err != nil {
+			fail(err)
+			return
+		}
+
+		// 1. 安全备份 回档结果不满意时可以恢复
+		outputChan <- &BackupMessage{MessageType: "info", Message: "正在创建安全备份..."}
+		if _, err := RunBackup(a, "区块回档前的自动备份"); err != nil {
+			fail(fmt.Errorf("安全备份失败，已取消回档: %w", err))
+			return
+		}
+		// 安全备份可能因为存储配额淘汰了目标快照
+		if target, err := GetBackupRecordBySnapShot(record.SnapShot); err != nil || target == nil {
+			fail(errors.New("目标快照已不存在，可能已被存储配额淘汰"))
+			return
+		}
+
+		// 2. 读取快照中的区域文件
+		outputChan <- &BackupMessage{MessageType: "info", Message: "正在读取快照中的区域文件..."}
+		files, err := SnapshotRegions(record.SnapShot, rollbackFolders...)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		// 按区域分组
+		var groups = make(map[string][]ChunkPos)
+		for _, chunk := range chunks {
+			rx, rz := region.RegionPos(chunk.X, chunk.Z)
+			var name = region.FileName(rx, rz)
+			groups[name] = append(groups[name], chunk)
+		}
+		var names = make([]string, 0, len(groups))
+		for name := range groups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		// 写入前再次确认 避免安全备份期间进入了存档
+		if err := CheckArchiveNotInUse(a); err != nil {
+			fail(err)
+			return
+		}
+
+		// 3. 逐个区域文件写回
+		var total = float64(len(names) * len(rollbackFolders))
+		var step int
+		for _, name := range names {
+			for _, folder := range rollbackFolders {
+				step++
+				outputChan <- &BackupMessage{
+					MessageType: "info",
+					PercentDone: float64(step) / total,
+					Message:     path.Join(folder, name),
+				}
+
+				var snapshotFile = files[path.Join(dimension, folder, name)]
+				if err := rollbackRegion(a, record.SnapShot, snapshotFile, dimension, folder, name, groups[name]); err != nil {
+					fail(fmt.Errorf("%s/%s: %w", folder, name, err))
+					return
+				}
+			}
+		}
+
+		outputChan <- &BackupMessage{
+			MessageType: "done",
+			Message:     fmt.Sprintf("已回档 %d 个区块", len(chunks)),
+			PercentDone: 1,
+		}
+	}()
+
+	return outputChan
+}
+
+// rollbackStagedSuffix 回档时暂存新文件的后缀 全部写入成功后才替换存档中的文件
+const rollbackStagedSuffix = ".rollback"
+
+// rollbackRegion 将快照中的区块写入存档中对应的区域文件
+// 快照中不存在的区块会被删除 游戏下次加载时重新生成
+// 新的区域文件与过大区块文件先写入暂存文件 全部成功后再替换 不再使用的过大区块文件最后删除
+func rollbackRegion(a *database.Archive, snapshot string, snapshotFile *SnapshotRegion, dimension, folder, name string, chunks []ChunkPos) error {
+	livePath, err := archiveRelPath(a, path.Join(dimension, folder, name))
+	if err != nil {
+		return err
+	}
+
+	var source = &region.File{}
+	if snapshotFile != nil && snapshotFile.Size > 0 {
+		var buf bytes.Buffer
+		if err := ResticDump(snapshot, snapshotFile.SnapshotPath, &buf); err != nil {
+			return err
+		}
+		if source, err = region.Parse(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	live, err := region.ReadFile(livePath)
+	if err != nil {
+		return err
+	}
+
+	var dir = filepath.Dir(livePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var now = uint32(time.Now().Unix())
+	var changed bool
+	var staged []string // 已暂存的过大区块文件 (最终路径)
+	var stale []string  // 替换后不再使用的过大区块文件
+	var removeStaged = func() {
+		for _, external := range staged {
+			_ = os.Remove(external + rollbackStagedSuffix)
+		}
+	}
+
+	for _, chunk := range chunks {
+		var index = region.ChunkIndex(chunk.X, chunk.Z)
+		var external = filepath.Join(dir, region.ExternalFileName(chunk.X, chunk.Z))
+		var wasExternal = live.IsExternal(index)
+
+		switch {
+		case source.Has(index):
+			live.SetChunk(index, source.Chunk(index), now)
+			changed = true
+
+			if source.IsExternal(index) {
+				var snapshotPath = path.Join(path.Dir(snapshotFile.SnapshotPath), region.ExternalFileName(chunk.X, chunk.Z))
+				if err := dumpFile(snapshot, snapshotPath, external+rollbackStagedSuffix); err != nil {
+					removeStaged()
+					return err
+				}
+				staged = append(staged, external)
+				continue
+			}
+		case live.Has(index):
+			live.RemoveChunk(index)
+			changed = true
+		}
+
+		// 旧的过大区块文件不再使用
+		if wasExternal {
+			stale = append(stale, external)
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	// 暂存区域文件
+	data, err := live.Bytes()
+	if err != nil {
+		removeStaged()
+		return err
+	}
+	if err := os.WriteFile(livePath+rollbackStagedSuffix, data, 0644); err != nil {
+		_ = os.Remove(livePath + rollbackStagedSuffix)
+		removeStaged()
+		return err
+	}
+
+	// 全部暂存成功后替换 先替换过大区块文件 再替换引用它们的区域文件
+	for _, external := range staged {
+		if err := os.Rename(external+rollbackStagedSuffix, external); err != nil {
+			_ = os.Remove(livePath + rollbackStagedSuffix)
+			removeStaged()
+			return err
+		}
+	}
+	if err := os.Rename(livePath+rollbackStagedSuffix, livePath); err != nil {
+		_ = os.Remove(livePath + rollbackStagedSuffix)
+		return err
+	}
+
+	for _, external := range stale {
+		if err := os.Remove(external); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// dumpToFile 将快照中的文件写入到指定位置
+func dumpToFile(snapshot, snapshotPath, target string) error {
+	var tmp = target + ".tmp"
+	if err := dumpFile(snapshot, snapshotPath, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+// dumpFile 将快照中的文件直接写入到指定路径 失败时删除写了一半的文件
+func dumpFile(snapshot, snapshotPath, target string) error {
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+
+	err = ResticDump(snapshot, snapshotPath, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(target)
+	}
+	return err
+}
+
+// archiveRelPath snapshotRelPath 的逆过程 将相对路径转换为存档中的实际路径
+// 存档有多个路径时 相对路径以备份路径的文件夹名称开头
+func archiveRelPath(a *database.Archive, rel string) (string, error) {
+	var roots = a.BackupPaths()
+	if len(roots) == 1 {
+		return filepath.Join(roots[0], filepath.FromSlash(rel)), nil
+	}
+
+	first, rest, _ := strings.Cut(rel, "/")
+	for _, root := range roots {
+		if strings.EqualFold(filepath.Base(root), first) {
+			return filepath.Join(root, filepath.FromSlash(rest)), nil
+		}
+	}
+	return "", fmt.Errorf("存档中没有与 %s 对应的路径", first)
+}
@@ -1,12 +1,14 @@
 package archive
 
 import (
+	"fmt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"log"
 	"minecraft-archive-backup/model/dto/database"
 	"minecraft-archive-backup/pkg/etc/core"
+	"os"
 	"path/filepath"
 )
 
@@ -14,25 +16,56 @@ var (
 	DB *gorm.DB
 )
 
+// dbPath 数据库文件路径
+var dbPath = filepath.Join(core.DataDir, "archive.sqlite")
+
+// pendingDBPath 恢复程序数据时写入的数据库 下次启动时替换 dbPath
+var pendingDBPath = dbPath + ".restore"
+
 func init() {
-	var (
-		err    error
-		dbPath = filepath.Join(core.DataDir, "archive.sqlite")
-	)
+	if err := applyPendingDB(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := openDB(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := RefreshArchiveCache(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// openDB 连接数据库 并创建数据表
+func openDB() error {
+	var err error
 
 	DB, err = gorm.Open(sqlite.Open(dbPath), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 
 	if err != nil {
-		log.Fatalf("无法连接数据库: %v", err)
+		return fmt.Errorf("无法连接数据库: %v", err)
 	}
 
-	if err = DB.AutoMigrate(&database.Archive{}, database.BackupRecord{}); err != nil {
-		log.Fatalf("数据库表创建失败: %v", err)
+	if err = DB.AutoMigrate(&database.Archive{}, database.BackupRecord{}, database.QuotaEviction{}, database.WorldInfo{}, database.ArchivePath{}); err != nil {
+		return fmt.Errorf("数据库表创建失败: %v", err)
 	}
 
-	if err := RefreshArchiveCache(); err != nil {
-		log.Fatal(err)
+	return nil
+}
+
+// applyPendingDB 用恢复的数据库替换当前的数据库 必须在连接数据库之前调用
+func applyPendingDB() error {
+	if _, err := os.Stat(pendingDBPath); err != nil {
+		return nil
+	}
+	// 旧数据库的日志文件不属于恢复的数据库
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		_ = os.Remove(dbPath + suffix)
+	}
+	if err := os.Rename(pendingDBPath, dbPath); err != nil {
+		return fmt.Errorf("替换恢复的数据库失败: %v", err)
 	}
+	return nil
 }
@@ -0,0 +1,122 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	etcRun "minecraft-archive-backup/pkg/etc/run"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var discoverConfig, _ = etcRun.LoadVipers("Discover")
+
+// DiscoverRoots 用户添加的扫描目录
+func DiscoverRoots() []string {
+	discoverConfig.Mu.RLock()
+	defer discoverConfig.Mu.RUnlock()
+	return discoverConfig.V.GetStringSlice("roots")
+}
+
+// AddDiscoverRoot 添加一个扫描目录
+func AddDiscoverRoot(root string) error {
+	root = strings.TrimSpace(root)
+	if root == "" {
+		return errors.New("扫描目录不能为空")
+	}
+
+	var roots = DiscoverRoots()
+	for _, r := range roots {
+		if samePath(r, root) {
+			return nil
+		}
+	}
+	return discoverConfig.SaveAtomic(map[string]any{"roots": append(roots, root)})
+}
+
+// RemoveDiscoverRoot 移除一个扫描目录
+func RemoveDiscoverRoot(root string) error {
+	var roots = slices.DeleteFunc(DiscoverRoots(), func(r string) bool {
+		return samePath(r, root)
+	})
+	return discoverConfig.SaveAtomic(map[string]any{"roots": roots})
+}
+
+// DiscoverWorlds 扫描常见启动器与用户添加的目录 返回尚未创建存档的世界
+func DiscoverWorlds() []*world.Candidate {
+	var roots = world.DefaultRoots()
+	for _, root := range DiscoverRoots() {
+		roots = append(roots, world.Root{Path: root})
+	}
+
+	// 已经创建存档的路径
+	var registered = make(map[string]bool)
+	for _, a := range LoadAllArchiveCache() {
+		for _, p := range a.BackupPaths() {
+			registered[pathKey(p)] = true
+		}
+	}
+
+	var candidates = world.Discover(roots)
+	return slices.DeleteFunc(candidates, func(c *world.Candidate) bool {
+		return registered[pathKey(c.Path)]
+	})
+}
+
+// ImportWorlds 为选中的世界创建存档 名称重复时自动添加序号
+// 返回成功创建的存档 遇到错误时停止 已创建的存档不会回滚
+func ImportWorlds(candidates []*world.Candidate) ([]*database.Archive, error) {
+	var names = make(map[string]bool)
+	for _, a := range LoadAllArchiveCache() {
+		names[a.Name] = true
+	}
+
+	var created []*database.Archive
+	for _, candidate := range candidates {
+		var a = &database.Archive{
+			Name:    uniqueName(candidate.Name, names),
+			Comment: fmt.Sprintf("从 %s 导入", candidate.Source),
+			Type:    database.ArchiveTypeWorld,
+			Path:    candidate.Path,
+		}
+		if candidate.Bedrock {
+			a.Type = database.ArchiveTypeBedrock
+		}
+
+		a, err := GetOrCreateArchiveCache(0, func() (*database.Archive, error) {
+			err := CreateArchive(a)
+			return a, err
+		})
+		if err != nil {
+			return created, fmt.Errorf("导入[ %s ]失败: %w", candidate.Name, err)
+		}
+
+		names[a.Name] = true
+		created = append(created, a)
+	}
+
+	return created, nil
+}
+
+// uniqueName 名称已被使用时 依次尝试 名称 (2)、名称 (3)...
+func uniqueName(name string, used map[string]bool) string {
+	if strings.TrimSpace(name) == "" {
+		name = "未命名存档"
+	}
+	if !used[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		var candidate = fmt.Sprintf("%s (%d)", name, i)
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+// pathKey 用于比较路径 Windows 的路径不区分大小写
+func pathKey(p string) string {
+	return strings.ToLower(filepath.Clean(p))
+}
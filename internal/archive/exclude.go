@@ -0,0 +1,226 @@
+package archive
+
+import (
+	"fmt"
+	"io/fs"
+	"minecraft-archive-backup/model/dto/database"
+	etc "minecraft-archive-backup/pkg/etc/core"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ExcludePreset 排除规则预设
+type ExcludePreset struct {
+	Name     string
+	Patterns []string
+}
+
+// ExcludePresets 常见的 Minecraft 排除规则预设
+// 以 / 开头的规则相对于存档根目录 其余规则匹配任意层级
+var ExcludePresets = []ExcludePreset{
+	{
+		Name: "原版",
+		Patterns: []string{
+			"session.lock",
+			"logs",
+			"crash-reports",
+			"debug",
+			"*.tmp",
+		},
+	},
+	{
+		Name: "Distant Horizons",
+		Patterns: []string{
+			"DistantHorizons.sqlite",
+			"DistantHorizons.sqlite-*",
+			"Distant_Horizons_server_data",
+		},
+	},
+	{
+		Name: "Chunky",
+		Patterns: []string{
+			"config/chunky/tasks",
+			"plugins/Chunky/tasks",
+		},
+	},
+	{
+		Name: "服务器插件",
+		Patterns: []string{
+			"plugins/dynmap/web/tiles",
+			"plugins/BlueMap/web/maps",
+			"bluemap/web/maps",
+			"plugins/squaremap/web/tiles",
+			"plugins/Pl3xMap/web/tiles",
+			"plugins/*/logs",
+			"/cache",
+			"/libraries",
+			"/versions",
+		},
+	},
+	{
+		Name: "模组缓存",
+		Patterns: []string{
+			".cache",
+			".fabric",
+			".mixin.out",
+			"mods/.connector",
+		},
+	},
+}
+
+// ExcludeStat 单条排除规则的预览结果
+type ExcludeStat struct {
+	Pattern string
+	Files   int
+	Bytes   int64
+}
+
+// ParseExcludes 将存档中保存的排除规则拆分为列表 忽略空行与 # 开头的注释
+func ParseExcludes(text string) []string {
+	var patterns []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns
+}
+
+// writeExcludeFile 将存档的排除规则写入文件 供 restic 的 --iexclude-file 使用
+// 没有规则时返回空字符串
+func writeExcludeFile(a *database.Archive, roots []string) (string, error) {
+	var patterns = ParseExcludes(a.Excludes)
+	if len(patterns) == 0 {
+		return "", nil
+	}
+
+	var lines = make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		// 以 / 开头的规则对每个路径分别生效
+		if strings.HasPrefix(pattern, "/") {
+			for _, root := range roots {
+				lines = append(lines, resticPattern(root, pattern))
+			}
+			continue
+		}
+		lines = append(lines, resticPattern("", pattern))
+	}
+
+	var dir = filepath.Join(etc.DataDir, "/restic/excludes")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建排除规则目录失败: %w", err)
+	}
+
+	var file = filepath.Join(dir, fmt.Sprintf("%d.txt", a.ID))
+	if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("写入排除规则失败: %w", err)
+	}
+	return file, nil
+}
+
+// resticPattern 将规则转换为 restic 的格式 以 / 开头的规则展开为存档下的绝对路径
+func resticPattern(root, pattern string) string {
+	if strings.HasPrefix(pattern, "/") {
+		return filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(pattern, "/")))
+	}
+	return filepath.FromSlash(pattern)
+}
+
+// PreviewExcludes 统计每条排除规则在存档各个路径中会排除的文件数量与大小
+// 同一个文件可能被多条规则匹配 因此各条规则的大小之和可能大于实际排除的大小
+func PreviewExcludes(roots []string, patterns []string) ([]ExcludeStat, error) {
+	var stats = make([]ExcludeStat, len(patterns))
+	for i, pattern := range patterns {
+		stats[i].Pattern = pattern
+	}
+
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			for i, pattern := range patterns {
+				if MatchExclude(pattern, filepath.ToSlash(rel)) {
+					stats[i].Files++
+					stats[i].Bytes += info.Size()
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
+
+// MatchExclude 判断相对存档根目录的路径是否被规则排除
+// 与 restic 一致 目录被匹配时其中的所有文件都会被排除
+func MatchExclude(pattern, rel string) bool {
+	var anchored = strings.HasPrefix(pattern, "/")
+	var patternParts = strings.Split(strings.Trim(filepath.ToSlash(pattern), "/"), "/")
+	var pathParts = strings.Split(rel, "/")
+
+	// 依次尝试路径的每个前缀 (目录本身被排除时 其内容也被排除)
+	for end := 1; end <= len(pathParts); end++ {
+		var prefix = pathParts[:end]
+		if anchored {
+			if matchParts(patternParts, prefix) {
+				return true
+			}
+			continue
+		}
+
+		// 未锚定的规则可以从任意层级开始匹配
+		for start := 0; start < end; start++ {
+			if matchParts(patternParts, prefix[start:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchParts 逐级匹配路径 支持 ** 匹配任意层级
+func matchParts(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchParts(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+
+	ok, err := path.Match(strings.ToLower(pattern[0]), strings.ToLower(parts[0]))
+	if err != nil || !ok {
+		return false
+	}
+	return matchParts(pattern[1:], parts[1:])
+}
@@ -0,0 +1,47 @@
+package archive
+
+import (
+	"fmt"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+)
+
+// readGameVersion 读取存档当前的游戏版本 没有世界或无法读取时 ok 为 false
+func readGameVersion(a *database.Archive) (dataVersion int, versionName string, ok bool) {
+	var worldPath = WorldPath(a)
+	if worldPath == "" {
+		return 0, "", false
+	}
+	level, err := world.ReadLevel(worldPath)
+	if err != nil || level.DataVersion == 0 {
+		return 0, "", false
+	}
+	return level.DataVersion, level.VersionName, true
+}
+
+// VersionLabel 版本的显示名称 旧的存档没有版本名称时显示数据版本号
+func VersionLabel(dataVersion int, versionName string) string {
+	if versionName != "" {
+		return versionName
+	}
+	return fmt.Sprintf("数据版本 %d", dataVersion)
+}
+
+// RestoreVersionWarning 回档到快照会改变游戏版本时的提示 版本相同或无法判断时返回空字符串
+// 快照版本较旧时游戏会再次升级存档 快照版本较新时用当前版本的游戏打开会损坏存档
+func RestoreVersionWarning(a *database.Archive, record *database.BackupRecord) string {
+	if record.DataVersion == 0 {
+		return ""
+	}
+	dataVersion, versionName, ok := readGameVersion(a)
+	if !ok || dataVersion == record.DataVersion {
+		return ""
+	}
+
+	var snapshot = VersionLabel(record.DataVersion, record.VersionName)
+	var current = VersionLabel(dataVersion, versionName)
+	if record.DataVersion > dataVersion {
+		return fmt.Sprintf("警告：该快照由 %s 创建，高于存档当前的 %s\n回档后必须使用 %s 或更高版本打开\n用旧版本的游戏打开会损坏存档！", snapshot, current, snapshot)
+	}
+	return fmt.Sprintf("注意：该快照由 %s 创建，存档当前为 %s\n回档后用 %s 打开会再次升级存档", snapshot, current, current)
+}
@@ -0,0 +1,231 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	"minecraft-archive-backup/pkg/nbt"
+	"minecraft-archive-backup/pkg/region"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ItemLocation 快照中找到物品的位置
+type ItemLocation struct {
+	Dimension string // 维度名称
+	Holder    string // 方块实体 ID 例如 minecraft:chest 或玩家名称
+	Player    bool   // 位于玩家背包或末影箱中 坐标为玩家的位置
+	Ender     bool   // 位于玩家的末影箱中
+	X, Y, Z   int
+	Count     int
+}
+
+// ItemSearchResult 单个快照的搜索结果
+type ItemSearchResult struct {
+	Record    database.BackupRecord
+	Locations []ItemLocation
+	Total     int
+	Err       error // 读取该快照失败 不影响其他快照
+}
+
+// searchFile 需要搜索的区域文件或玩家文件
+type searchFile struct {
+	node      *LsNode
+	dimension string // 区域文件所在的维度 玩家文件为空
+	player    bool
+}
+
+// key 同一个文件在不同快照中大小与修改时间都相同时 内容视为相同
+func (f *searchFile) key() string {
+	return fmt.Sprintf("%s|%d|%d", f.node.Path, f.node.Size, f.node.MTime.UnixNano())
+}
+
+// SearchItems 在快照的方块实体与玩家数据中查找物品 结果按快照时间排序
+// 没有变化的文件只读取一次 onProgress 在每个快照搜索完成后调用
+func SearchItems(a *database.Archive, records []database.BackupRecord, itemID string, onProgress func(done, total int)) []ItemSearchResult {
+	itemID = world.NormalizeItemID(itemID)
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+
+	// 玩家名称
+	var names = make(map[string]string)
+	if entries, err := world.ReadUserCache(userCachePath(a)); err == nil {
+		for _, entry := range entries {
+			names[strings.ToLower(entry.UUID)] = entry.Name
+		}
+	}
+
+	var cache = make(map[string][]ItemLocation)
+	var results = make([]ItemSearchResult, 0, len(records))
+	for i, record := range records {
+		var result = ItemSearchResult{Record: record}
+		result.Locations, result.Err = searchSnapshot(record.SnapShot, itemID, names, cache)
+		for _, location := range result.Locations {
+			result.Total += location.Count
+		}
+		results = append(results, result)
+
+		if onProgress != nil {
+			onProgress(i+1, len(records))
+		}
+	}
+	return results
+}
+
+// searchSnapshot 在单个快照中查找物品 cache 保存已经读取过的文件的结果
+func searchSnapshot(snapshot, itemID string, names map[string]string, cache map[string][]ItemLocation) ([]ItemLocation, error) {
+	roots, nodes, err := listSnapshot(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*searchFile
+	for _, r := range regionsFromNodes(roots, nodes, world.RegionDir) {
+		files = append(files, &searchFile{
+			node:      &LsNode{Path: r.SnapshotPath, Size: r.Size, MTime: r.MTime},
+			dimension: r.Dimension,
+		})
+	}
+	for _, node := range nodes {
+		var rel = snapshotRelPath(roots, node.Path)
+		if node.Type == "file" && path.Base(path.Dir(rel)) == world.PlayerDataDir && strings.HasSuffix(node.Name, ".dat") {
+			files = append(files, &searchFile{node: node, player: true})
+		}
+	}
+
+	var (
+		locations []ItemLocation
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		firstErr  error
+		sem       = make(chan struct{}, dumpWorkers)
+	)
+	for _, file := range files {
+		mu.Lock()
+		cached, ok := cache[file.key()]
+		if ok {
+			locations = append(locations, cached...)
+		}
+		mu.Unlock()
+		if ok {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			found, err := searchFileItems(snapshot, file, itemID, names)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			cache[file.key()] = found
+			locations = append(locations, found...)
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].Count != locations[j].Count {
+			return locations[i].Count > locations[j].Count
+		}
+		return locations[i].Holder < locations[j].Holder
+	})
+	return locations, nil
+}
+
+// searchFileItems 读取单个文件并查找物品
+func searchFileItems(snapshot string, file *searchFile, itemID string, names map[string]string) ([]ItemLocation, error) {
+	var buf bytes.Buffer
+	if err := ResticDump(snapshot, file.node.Path, &buf); err != nil {
+		return nil, err
+	}
+
+	if file.player {
+		return searchPlayerItems(buf.Bytes(), file.node.Name, itemID, names)
+	}
+
+	regionFile, err := region.Parse(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", path.Base(file.node.Path), err)
+	}
+
+	var locations []ItemLocation
+	for i := 0; i < region.ChunksPerRegion; i++ {
+		var payload = regionFile.Payload(i)
+		if payload == nil {
+			continue
+		}
+		// 单个区块损坏时跳过 不影响其他区块
+		root, err := nbt.DecodeBytes(payload)
+		if err != nil {
+			continue
+		}
+		for _, container := range world.FindChunkItems(root, itemID) {
+			locations = append(locations, ItemLocation{
+				Dimension: world.DimensionName(file.dimension),
+				Holder:    container.ID,
+				X:         container.X,
+				Y:         container.Y,
+				Z:         container.Z,
+				Count:     container.Count,
+			})
+		}
+	}
+	return locations, nil
+}
+
+// searchPlayerItems 在玩家的背包与末影箱中查找物品
+func searchPlayerItems(data []byte, fileName, itemID string, names map[string]string) ([]ItemLocation, error) {
+	root, err := nbt.DecodeBytes(data)
+	if err != nil {
+		// 损坏的玩家文件不影响搜索
+		return nil, nil
+	}
+
+	var uuid = strings.TrimSuffix(fileName, ".dat")
+	var holder = names[strings.ToLower(uuid)]
+	if holder == "" {
+		holder = uuid
+	}
+
+	var summary = world.ParsePlayer(root)
+	var location = ItemLocation{
+		Dimension: world.DimensionIDName(summary.Dimension),
+		Holder:    holder,
+		Player:    true,
+		X:         int(summary.X),
+		Y:         int(summary.Y),
+		Z:         int(summary.Z),
+	}
+
+	var locations []ItemLocation
+	inventory, ender := world.PlayerItems(root, itemID)
+	if inventory > 0 {
+		var l = location
+		l.Count = inventory
+		locations = append(locations, l)
+	}
+	if ender > 0 {
+		var l = location
+		l.Ender, l.Count = true, ender
+		locations = append(locations, l)
+	}
+	return locations, nil
+}
@@ -0,0 +1,169 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	etc "minecraft-archive-backup/pkg/etc/core"
+	"minecraft-archive-backup/pkg/nbt"
+	"minecraft-archive-backup/pkg/region"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 地图预览的半径 (区块)
+const (
+	DefaultPreviewRadius = 16
+	MaxPreviewRadius     = 64
+)
+
+// MapArea 地图预览的范围
+type MapArea struct {
+	Spawn  bool // 以快照中 level.dat 的出生点为中心 忽略 X 与 Z
+	X, Z   int  // 中心的方块坐标
+	Radius int  // 半径 (区块)
+}
+
+// previewDir 地图预览的缓存目录
+func previewDir() string {
+	return filepath.Join(etc.DataDir, "preview")
+}
+
+// cachePath 预览图的缓存路径 同一个快照的内容不会变化 可以一直使用
+func (area MapArea) cachePath(snapshot string) string {
+	if area.Spawn {
+		return filepath.Join(previewDir(), fmt.Sprintf("%s_spawn_%d.png", snapshot, area.Radius))
+	}
+	return filepath.Join(previewDir(), fmt.Sprintf("%s_%d_%d_%d.png", snapshot, area.X, area.Z, area.Radius))
+}
+
+// CachedMapPreview 已经渲染过的预览图路径 没有缓存时返回空字符串
+func CachedMapPreview(record *database.BackupRecord, area MapArea) string {
+	var p = area.cachePath(record.SnapShot)
+	if _, err := os.Stat(p); err != nil {
+		return ""
+	}
+	return p
+}
+
+// RenderMapPreview 根据快照中主世界的区域文件渲染俯视图 返回 PNG 的路径
+// 只读取范围内的区域文件 结果按快照缓存
+func RenderMapPreview(a *database.Archive, record *database.BackupRecord, area MapArea) (string, error) {
+	if area.Radius <= 0 || area.Radius > MaxPreviewRadius {
+		return "", fmt.Errorf("预览半径必须在 1 到 %d 个区块之间", MaxPreviewRadius)
+	}
+	if p := CachedMapPreview(record, area); p != "" {
+		return p, nil
+	}
+
+	worldPath, err := snapshotWorldPath(a, record.SnapShot)
+	if err != nil {
+		return "", err
+	}
+
+	// 以出生点为中心
+	if area.Spawn {
+		var buf bytes.Buffer
+		if err := ResticDump(record.SnapShot, path.Join(worldPath, world.LevelDatName), &buf); err != nil {
+			return "", err
+		}
+		root, err := nbt.DecodeBytes(buf.Bytes())
+		if err != nil {
+			return "", fmt.Errorf("解析 level.dat 失败: %w", err)
+		}
+		level, err := world.ParseLevel(root)
+		if err != nil {
+			return "", err
+		}
+		area.X, area.Z = level.SpawnX, level.SpawnZ
+	}
+
+	var centerX, centerZ = area.X >> 4, area.Z >> 4
+	var minCX, minCZ = centerX - area.Radius, centerZ - area.Radius
+	var maxCX, maxCZ = centerX + area.Radius, centerZ + area.Radius
+	var size = (area.Radius*2 + 1) * world.ChunkWidth
+	var image = world.NewMapImage(minCX*world.ChunkWidth, minCZ*world.ChunkWidth, size, size)
+
+	var minRX, minRZ = region.RegionPos(minCX, minCZ)
+	var maxRX, maxRZ = region.RegionPos(maxCX, maxCZ)
+	var drawn int
+	for rx := minRX; rx <= maxRX; rx++ {
+		for rz := minRZ; rz <= maxRZ; rz++ {
+			var buf bytes.Buffer
+			var file = path.Join(worldPath, world.RegionDir, region.FileName(rx, rz))
+			if err := ResticDump(record.SnapShot, file, &buf); err != nil {
+				if errors.Is(err, ErrNotInSnapshot) {
+					continue
+				}
+				return "", err
+			}
+			regionFile, err := region.Parse(buf.Bytes())
+			if err != nil {
+				return "", fmt.Errorf("解析 %s 失败: %w", region.FileName(rx, rz), err)
+			}
+
+			for i := 0; i < region.ChunksPerRegion; i++ {
+				cx, cz := region.ChunkPos(rx, rz, i)
+				if cx < minCX || cx > maxCX || cz < minCZ || cz > maxCZ {
+					continue
+				}
+				var payload = regionFile.Payload(i)
+				if payload == nil {
+					continue
+				}
+
+				// 单个区块损坏或使用 LZ4 压缩时跳过 不影响其他区块
+				root, err := nbt.DecodeBytes(payload)
+				if err != nil {
+					continue
+				}
+				if surface := world.ParseSurface(root); surface != nil {
+					image.DrawChunk(cx, cz, surface)
+					drawn++
+				}
+			}
+		}
+	}
+	if drawn == 0 {
+		return "", errors.New("所选范围内没有已生成的区块")
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.Image()); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(previewDir(), 0755); err != nil {
+		return "", err
+	}
+	var target = area.cachePath(record.SnapShot)
+	if err := os.WriteFile(target+".tmp", buf.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return target, os.Rename(target+".tmp", target)
+}
+
+// renameMapPreviews 快照ID变化后 将预览图改为新的ID 改名失败的预览图直接删除 下次重新渲染
+func renameMapPreviews(oldID, newID string) {
+	matches, _ := filepath.Glob(filepath.Join(previewDir(), oldID+"_*.png"))
+	for _, match := range matches {
+		var target = filepath.Join(previewDir(), newID+strings.TrimPrefix(filepath.Base(match), oldID))
+		if err := os.Rename(match, target); err != nil {
+			_ = os.Remove(match)
+		}
+	}
+}
+
+// removeMapPreviews 删除快照的所有预览图
+func removeMapPreviews(snapshots ...string) {
+	for _, snapshot := range snapshots {
+		matches, _ := filepath.Glob(filepath.Join(previewDir(), snapshot+"_*.png"))
+		for _, match := range matches {
+			_ = os.Remove(match)
+		}
+	}
+}
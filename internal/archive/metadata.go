@@ -0,0 +1,199 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"minecraft-archive-backup/model/dto/database"
+	etc "minecraft-archive-backup/pkg/etc/core"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// MetadataTag 程序数据快照使用的 restic 标签
+const MetadataTag = "app-metadata"
+
+// ErrNoArchives 还没有任何存档 不备份程序数据
+// 新安装的程序指向旧仓库时 避免空的存档列表成为最新的程序数据快照 导致恢复时恢复成空列表
+var ErrNoArchives = errors.New("还没有任何存档，无需备份程序数据")
+
+// metadataDir 导出程序数据的暂存目录 备份的就是这个目录
+var metadataDir = filepath.Join(etc.DataDir, "metadata")
+
+// ExportMetadata 将数据库与配置文件导出到暂存目录
+// 数据库使用 VACUUM INTO 导出 得到一份一致的副本 不受正在进行的写入影响
+func ExportMetadata() (string, error) {
+	if err := os.RemoveAll(metadataDir); err != nil {
+		return "", fmt.Errorf("清理暂存目录失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(metadataDir, "config"), 0755); err != nil {
+		return "", fmt.Errorf("创建暂存目录失败: %w", err)
+	}
+
+	// 1. 导出数据库
+	var dbFile = filepath.Join(metadataDir, filepath.Base(dbPath))
+	if err := DB.Exec("VACUUM INTO ?", dbFile).Error; err != nil {
+		return "", fmt.Errorf("导出数据库失败: %w", err)
+	}
+	// 沿用原文件的修改时间 数据没有变化时 restic 可以跳过这次备份
+	if info, err := os.Stat(dbPath); err == nil {
+		_ = os.Chtimes(dbFile, info.ModTime(), info.ModTime())
+	}
+
+	// 2. 复制配置文件
+	configs, err := filepath.Glob(filepath.Join(etc.ConfigDir, "*.yaml"))
+	if err != nil {
+		return "", err
+	}
+	for _, config := range configs {
+		var dst = filepath.Join(metadataDir, "config", filepath.Base(config))
+		if _, err := copyFile(config, dst); err != nil {
+			return "", fmt.Errorf("复制配置文件失败: %w", err)
+		}
+		if info, err := os.Stat(config); err == nil {
+			_ = os.Chtimes(dst, info.ModTime(), info.ModTime())
+		}
+	}
+
+	return metadataDir, nil
+}
+
+// BackupMetadata 导出程序数据 并以带标签的快照存入当前仓库
+// 数据没有变化时不会创建新的快照 返回的摘要中快照ID为空 没有任何存档时返回 ErrNoArchives
+func BackupMetadata() (*BackupMessage, error) {
+	var count int64
+	if err := DB.Model(&database.Archive{}).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询存档数量失败: %w", err)
+	}
+	if count == 0 {
+		return nil, ErrNoArchives
+	}
+
+	repoMu.RLock()
+	defer repoMu.RUnlock()
+
+	dir, err := ExportMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := NewResticCmd(exec.Command("restic", "backup", dir,
+		"--json",
+		"--host", ResticHost(),
+		"--tag", MetadataTag,
+		"--skip-if-unchanged",
+	))
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("备份程序数据失败: %v", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		var msg BackupMessage
+		if json.Unmarshal(scanner.Bytes(), &msg) == nil && msg.MessageType == "summary" {
+			return &msg, nil
+		}
+	}
+
+	return nil, errors.New("备份程序数据没有返回摘要信息")
+}
+
+// RestoreMetadata 从仓库中恢复最近一次备份的程序数据 用于在新安装的程序中找回存档列表
+// repoPath 与 password 为需要打开的仓库 恢复完成后程序会切换到该仓库
+// 数据库在下次启动时替换 调用前需要停止定时任务 恢复后需要重启程序
+func RestoreMetadata(repoPath, password string) error {
+	if strings.TrimSpace(repoPath) == "" {
+		return errors.New("仓库路径不能为空")
+	}
+
+	// 1. 查找最近一次的程序数据快照
+	listCmd := newResticCmdWithAuth(exec.Command("restic", "snapshots", "--json",
+		"--tag", MetadataTag, "--latest", "1"), repoPath, password)
+	output, err := listCmd.Output()
+	if err != nil {
+		return fmt.Errorf("无法打开仓库，请检查路径与密码: %v", err)
+	}
+
+	var snapshots []*SnapshotMessage
+	if err := json.Unmarshal(output, &snapshots); err != nil {
+		return fmt.Errorf("解析快照列表失败: %w", err)
+	}
+	// --latest 会按主机与路径分组 取所有分组中最新的一个
+	var latest *SnapshotMessage
+	for _, snapshot := range snapshots {
+		if len(snapshot.Paths) > 0 && (latest == nil || snapshot.Time.After(latest.Time)) {
+			latest = snapshot
+		}
+	}
+	if latest == nil {
+		return errors.New("仓库中没有程序数据的备份")
+	}
+
+	// 2. 恢复到临时目录
+	var target = filepath.Join(etc.DataDir, "metadata-restore")
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("清理临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(target)
+
+	restoreCmd := newResticCmdWithAuth(exec.Command("restic", "restore",
+		fmt.Sprintf("%s:%s", latest.Id, ConvertWindowsToUnixPath(latest.Paths[0])),
+		"--target", target), repoPath, password)
+	if output, err := restoreCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("恢复程序数据失败: %v\n输出: %s", err, output)
+	}
+
+	// 3. 替换配置文件
+	configs, err := filepath.Glob(filepath.Join(target, "config", "*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		if err := replaceFile(config, filepath.Join(etc.ConfigDir, filepath.Base(config))); err != nil {
+			return fmt.Errorf("恢复配置文件失败: %w", err)
+		}
+	}
+
+	// 重新读取恢复后的配置
+	for _, config := range []*etc.SafeViper{resticConfig, discoverConfig} {
+		config.Mu.Lock()
+		err = config.V.ReadInConfig()
+		config.Mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("读取恢复的配置失败: %w", err)
+		}
+	}
+
+	// 恢复的配置中记录的是原来电脑上的仓库 改为当前打开的仓库
+	if err := resticConfig.SaveAtomic(map[string]any{
+		"repository":     repoPath,
+		"password":       password,
+		"old_repository": "",
+	}); err != nil {
+		return err
+	}
+
+	// 4. 数据库不能在使用中替换 写入待替换的文件 下次启动时生效
+	if err := replaceFile(filepath.Join(target, filepath.Base(dbPath)), pendingDBPath); err != nil {
+		return fmt.Errorf("恢复数据库失败: %w", err)
+	}
+	return nil
+}
+
+// replaceFile 先复制到目标旁的临时文件 再重命名覆盖目标
+func replaceFile(src, dst string) error {
+	var tmp = dst + ".tmp"
+	_ = os.Remove(tmp)
+
+	if _, err := copyFile(src, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
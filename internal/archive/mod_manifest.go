@@ -0,0 +1,190 @@
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// modCache 已经计算过的模组文件 以路径、大小与修改时间区分 避免每次备份都重新计算 SHA-1
+var modCache sync.Map
+
+// InstancePath 存档对应的游戏实例目录 没有实例时返回空字符串
+// 世界位于 <实例>/saves/<世界> 中 服务器的模组位于服务器根目录
+func InstancePath(a *database.Archive) string {
+	switch NormalizeArchiveType(a.Type) {
+	case database.ArchiveTypeInstance, database.ArchiveTypeServer:
+		return a.Path
+	case database.ArchiveTypeWorld:
+		if saves := filepath.Dir(a.Path); strings.EqualFold(filepath.Base(saves), "saves") {
+			return filepath.Dir(saves)
+		}
+	}
+	return ""
+}
+
+// CaptureManifest 读取存档当前的模组、数据包与加载器
+func CaptureManifest(a *database.Archive) *world.ModManifest {
+	var manifest = &world.ModManifest{}
+	if worldPath := WorldPath(a); worldPath != "" && NormalizeArchiveType(a.Type) != database.ArchiveTypeBedrock {
+		manifest.Datapacks = world.ListDatapacks(worldPath)
+	}
+
+	var instance = InstancePath(a)
+	if instance == "" {
+		return manifest
+	}
+	if NormalizeArchiveType(a.Type) == database.ArchiveTypeServer {
+		manifest.Loader = world.DetectServerLoader(instance)
+	} else {
+		manifest.Loader = world.DetectLoader(instance)
+	}
+
+	for _, jar := range world.ListModJars(instance) {
+		info, err := os.Stat(jar)
+		if err != nil {
+			continue
+		}
+		var key = fmt.Sprintf("%s|%d|%d", jar, info.Size(), info.ModTime().UnixNano())
+		if cached, ok := modCache.Load(key); ok {
+			manifest.Mods = append(manifest.Mods, cached.(world.ModFile))
+			continue
+		}
+		mod, err := world.ReadModJar(jar)
+		if err != nil {
+			continue
+		}
+		modCache.Store(key, mod)
+		manifest.Mods = append(manifest.Mods, mod)
+	}
+	return manifest
+}
+
+// manifestJSON 保存到备份记录中的清单 原版存档没有清单
+func manifestJSON(a *database.Archive) string {
+	var manifest = CaptureManifest(a)
+	if manifest.Empty() {
+		return ""
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// ParseManifest 解析备份记录中的清单 没有清单时返回 nil
+func ParseManifest(record *database.BackupRecord) (*world.ModManifest, error) {
+	if record.Manifest == "" {
+		return nil, nil
+	}
+	var manifest world.ModManifest
+	if err := json.Unmarshal([]byte(record.Manifest), &manifest); err != nil {
+		return nil, fmt.Errorf("解析模组清单失败: %w", err)
+	}
+	return &manifest, nil
+}
+
+// PreviousManifestRecord 同一存档中早于该记录且带有清单的最近一条记录 没有时返回 nil
+func PreviousManifestRecord(record *database.BackupRecord) (*database.BackupRecord, error) {
+	var previous database.BackupRecord
+	result := DB.Where("archive_id = ? AND created_at < ? AND manifest <> ''", record.ArchiveID, record.CreatedAt).
+		Order("created_at DESC").First(&previous)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &previous, result.Error
+}
+
+// ManifestDiff 两个清单之间的差异 模组以第一个模组 ID 区分 没有 ID 时以文件名区分
+type ManifestDiff struct {
+	OldLoader, NewLoader     string // 加载器没有变化时为空
+	AddedMods, RemovedMods   []string
+	UpdatedMods              []string // 文件内容变化的模组 例如 "sodium 0.5.8 → 0.5.11"
+	AddedPacks, RemovedPacks []string
+}
+
+// Empty 两个清单相同
+func (d *ManifestDiff) Empty() bool {
+	return d.OldLoader == d.NewLoader && len(d.AddedMods) == 0 && len(d.RemovedMods) == 0 &&
+		len(d.UpdatedMods) == 0 && len(d.AddedPacks) == 0 && len(d.RemovedPacks) == 0
+}
+
+// DiffManifests 比较旧清单与新清单
+func DiffManifests(old, current *world.ModManifest) *ManifestDiff {
+	var diff = &ManifestDiff{}
+	if old.Loader != current.Loader {
+		diff.OldLoader, diff.NewLoader = old.Loader, current.Loader
+	}
+
+	var oldMods = make(map[string]world.ModFile)
+	for _, mod := range old.Mods {
+		oldMods[modKey(mod)] = mod
+	}
+	var newMods = make(map[string]bool)
+	for _, mod := range current.Mods {
+		var key = modKey(mod)
+		newMods[key] = true
+		previous, ok := oldMods[key]
+		switch {
+		case !ok:
+			diff.AddedMods = append(diff.AddedMods, ModLabel(mod))
+		case previous.SHA1 != mod.SHA1:
+			diff.UpdatedMods = append(diff.UpdatedMods, fmt.Sprintf("%s %s → %s", key, modVersion(previous), modVersion(mod)))
+		}
+	}
+	for _, mod := range old.Mods {
+		if !newMods[modKey(mod)] {
+			diff.RemovedMods = append(diff.RemovedMods, ModLabel(mod))
+		}
+	}
+
+	var oldPacks = make(map[string]bool)
+	for _, pack := range old.Datapacks {
+		oldPacks[pack] = true
+	}
+	var newPacks = make(map[string]bool)
+	for _, pack := range current.Datapacks {
+		newPacks[pack] = true
+		if !oldPacks[pack] {
+			diff.AddedPacks = append(diff.AddedPacks, pack)
+		}
+	}
+	for _, pack := range old.Datapacks {
+		if !newPacks[pack] {
+			diff.RemovedPacks = append(diff.RemovedPacks, pack)
+		}
+	}
+	return diff
+}
+
+// ModLabel 模组的显示名称 例如 "sodium 0.5.11 (sodium-fabric-0.5.11.jar)"
+func ModLabel(mod world.ModFile) string {
+	if len(mod.Mods) == 0 {
+		return mod.File
+	}
+	return fmt.Sprintf("%s %s (%s)", modKey(mod), modVersion(mod), mod.File)
+}
+
+// modKey 区分模组的键
+func modKey(mod world.ModFile) string {
+	if len(mod.Mods) > 0 && mod.Mods[0].ID != "" {
+		return mod.Mods[0].ID
+	}
+	return mod.File
+}
+
+// modVersion 模组的版本 没有版本时为文件名
+func modVersion(mod world.ModFile) string {
+	if len(mod.Mods) > 0 && mod.Mods[0].Version != "" {
+		return mod.Mods[0].Version
+	}
+	return mod.File
+}
@@ -0,0 +1,176 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	"minecraft-archive-backup/pkg/nbt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Player 存档中的玩家
+type Player struct {
+	UUID string
+	Name string // usercache.json 中没有记录时为空
+}
+
+// DisplayName 有名称时显示名称 否则显示 UUID
+func (p Player) DisplayName() string {
+	if p.Name == "" {
+		return p.UUID
+	}
+	return fmt.Sprintf("%s (%s)", p.Name, p.UUID[:min(8, len(p.UUID))])
+}
+
+// playerWorldRel 玩家数据所在的世界 相对存档主路径 (以 / 分隔)
+// 服务器为 level-name 指定的主世界 单人世界为存档本身
+func playerWorldRel(a *database.Archive) string {
+	if NormalizeArchiveType(a.Type) == database.ArchiveTypeServer {
+		return world.ServerLevelName(a.Path)
+	}
+	return ""
+}
+
+// userCachePath usercache.json 的位置 服务器位于根目录 单人世界位于 .minecraft (saves 的上一级)
+func userCachePath(a *database.Archive) string {
+	if NormalizeArchiveType(a.Type) == database.ArchiveTypeServer {
+		return filepath.Join(a.Path, world.UserCacheName)
+	}
+	return filepath.Join(filepath.Dir(filepath.Dir(a.Path)), world.UserCacheName)
+}
+
+// ListPlayers 列出存档中的玩家 通过 usercache.json 将 UUID 对应到玩家名称
+func ListPlayers(a *database.Archive) ([]Player, error) {
+	entries, err := world.ReadUserCache(userCachePath(a))
+	if err != nil {
+		return nil, fmt.Errorf("读取 usercache.json 失败: %w", err)
+	}
+	var names = make(map[string]string, len(entries))
+	for _, entry := range entries {
+		names[strings.ToLower(entry.UUID)] = entry.Name
+	}
+
+	var players []Player
+	for _, uuid := range world.PlayerUUIDs(filepath.Join(a.Path, filepath.FromSlash(playerWorldRel(a)))) {
+		players = append(players, Player{UUID: uuid, Name: names[strings.ToLower(uuid)]})
+	}
+
+	// 有名称的玩家在前 按名称排序
+	sort.Slice(players, func(i, j int) bool {
+		if (players[i].Name == "") != (players[j].Name == "") {
+			return players[i].Name != ""
+		}
+		return strings.ToLower(players[i].Name+players[i].UUID) < strings.ToLower(players[j].Name+players[j].UUID)
+	})
+	return players, nil
+}
+
+// snapshotWorldPath 快照中玩家数据所在世界的路径
+func snapshotWorldPath(a *database.Archive, snapshot string) (string, error) {
+	info, err := ResticSnapshotInfo(snapshot)
+	if err != nil {
+		return "", fmt.Errorf("查询快照信息失败: %w", err)
+	}
+	if len(info.Paths) == 0 {
+		return "", errors.New("快照中没有备份路径")
+	}
+
+	// 存档有多个路径时 玩家数据位于主路径中
+	var root = info.Paths[0]
+	for _, p := range info.Paths {
+		if strings.EqualFold(path.Base(ConvertWindowsToUnixPath(p)), filepath.Base(a.Path)) {
+			root = p
+			break
+		}
+	}
+
+	return path.Join(ConvertWindowsToUnixPath(root), playerWorldRel(a)), nil
+}
+
+// SnapshotPlayer 读取快照中玩家的概要 快照中没有该玩家时返回 nil
+func SnapshotPlayer(a *database.Archive, record *database.BackupRecord, uuid string) (*world.PlayerSummary, error) {
+	worldPath, err := snapshotWorldPath(a, record.SnapShot)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	var file = path.Join(worldPath, world.PlayerFiles(uuid, false, false)[0])
+	if err := ResticDump(record.SnapShot, file, &buf); err != nil {
+		if errors.Is(err, ErrNotInSnapshot) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	root, err := nbt.DecodeBytes(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("解析玩家数据失败: %w", err)
+	}
+	return world.ParsePlayer(root), nil
+}
+
+// RestorePlayer 从快照中恢复单个玩家的数据 可选择一并恢复统计信息与进度
+// 存档正在被使用时拒绝执行 恢复之前会先创建一个安全备份
+func RestorePlayer(a *database.Archive, record *database.BackupRecord, uuid string, stats, advancements bool) error {
+	if err := CheckArchiveNotInUse(a); err != nil {
+		return err
+	}
+
+	worldPath, err := snapshotWorldPath(a, record.SnapShot)
+	if err != nil {
+		return err
+	}
+
+	// 只恢复快照中存在的文件
+	nodes, err := ResticLs(record.SnapShot)
+	if err != nil {
+		return err
+	}
+	var existing = make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		existing[node.Path] = true
+	}
+
+	var files = world.PlayerFiles(uuid, stats, advancements)
+	if !existing[path.Join(worldPath, files[0])] {
+		return errors.New("所选快照中没有该玩家的数据")
+	}
+
+	// 安全备份 恢复结果不满意时可以找回
+	if _, err := RunBackup(a, "玩家回档前的自动备份"); err != nil {
+		return fmt.Errorf("安全备份失败，已取消回档: %w", err)
+	}
+	// 安全备份可能因为存储配额淘汰了目标快照
+	if target, err := GetBackupRecordBySnapShot(record.SnapShot); err != nil || target == nil {
+		return errors.New("目标快照已不存在，可能已被存储配额淘汰")
+	}
+	// 写入前再次确认 避免安全备份期间进入了存档
+	if err := CheckArchiveNotInUse(a); err != nil {
+		return err
+	}
+
+	var liveWorld = filepath.Join(a.Path, filepath.FromSlash(playerWorldRel(a)))
+	for _, file := range files {
+		var snapshotFile = path.Join(worldPath, file)
+		if !existing[snapshotFile] {
+			continue
+		}
+
+		var target = filepath.Join(liveWorld, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := dumpToFile(record.SnapShot, snapshotFile, target); err != nil {
+			return fmt.Errorf("恢复 %s 失败: %w", file, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package archive
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"minecraft-archive-backup/model/dto/database"
+)
+
+// EnforceQuota 备份前检查存档配额 超出时从最旧的未固定快照开始淘汰 直到新快照可以放下
+// parent 为本次备份的父快照 不会被淘汰
+// 返回被淘汰的备份记录
+func EnforceQuota(a *database.Archive, parent string) ([]database.BackupRecord, error) {
+	if a.Quota <= 0 {
+		return nil, nil
+	}
+
+	// 估算新快照的新增数据量
+	summary, err := ResticBackupDryRun(a, parent)
+	if err != nil {
+		return nil, err
+	}
+	var estimate = summary.DataAdded
+
+	// 新快照本身就放不下 淘汰也没有意义 不淘汰任何快照 备份照常进行
+	if estimate > a.Quota {
+		log.Printf("[配额] 存档[ %s ]新快照预计新增 %d 字节 超过配额 %d 字节 不进行淘汰\n", a.Name, estimate, a.Quota)
+		return nil, nil
+	}
+
+	// 从旧到新排列的备份记录
+	var records []database.BackupRecord
+	if err := DB.Where("archive_id = ?", a.ID).Order("created_at ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询备份记录失败: %w", err)
+	}
+
+	var snapshots = make([]string, 0, len(records))
+	for _, r := range records {
+		snapshots = append(snapshots, r.SnapShot)
+	}
+
+	usage, err := archiveUsage(snapshots)
+	if err != nil {
+		return nil, err
+	}
+	if usage+estimate <= a.Quota {
+		return nil, nil
+	}
+
+	// 可淘汰的快照 从旧到新 固定的快照与本次备份的父快照不会被淘汰
+	var candidates []int
+	for i, r := range records {
+		if !r.Pinned && r.SnapShot != parent {
+			candidates = append(candidates, i)
+		}
+	}
+
+	// 淘汰最旧的 count 个快照后剩余快照占用的空间 结果缓存 避免重复统计
+	var lefts = map[int]int64{0: usage}
+	var leftAfter = func(count int) (int64, error) {
+		if left, ok := lefts[count]; ok {
+			return left, nil
+		}
+		var evict = make(map[int]bool, count)
+		for _, i := range candidates[:count] {
+			evict[i] = true
+		}
+		var rest = make([]string, 0, len(records)-count)
+		for i, r := range records {
+			if !evict[i] {
+				rest = append(rest, r.SnapShot)
+			}
+		}
+		left, err := archiveUsage(rest)
+		if err != nil {
+			return 0, err
+		}
+		lefts[count] = left
+		return left, nil
+	}
+
+	all, err := leftAfter(len(candidates))
+	if err != nil {
+		return nil, err
+	}
+	if all+estimate > a.Quota {
+		return nil, fmt.Errorf("淘汰所有可淘汰的快照后仍超出配额: 占用 %.2fMB，预计新增 %.2fMB，配额 %.2fMB",
+			float64(all)/1048576, float64(estimate)/1048576, float64(a.Quota)/1048576)
+	}
+
+	// 剩余占用随淘汰数量单调递减 二分查找最少需要淘汰的数量 只需统计 log(n) 次
+	var low, high = 1, len(candidates)
+	for low < high {
+		var mid = (low + high) / 2
+		left, err := leftAfter(mid)
+		if err != nil {
+			return nil, err
+		}
+		if left+estimate <= a.Quota {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+
+	var evicted = make([]database.BackupRecord, 0, low)
+	for _, i := range candidates[:low] {
+		evicted = append(evicted, records[i])
+	}
+
+	// 一次性删除并清理所有被淘汰的快照
+	var forget = make([]string, 0, len(evicted))
+	for _, r := range evicted {
+		forget = append(forget, r.SnapShot)
+	}
+	if err := resticForget(forget...); err != nil {
+		return nil, err
+	}
+
+	var left = lefts[low]
+	log.Printf("[配额] 存档[ %s ]淘汰 %d 个快照 占用 %d 字节 -> %d 字节 预计新增 %d 字节 配额 %d 字节\n",
+		a.Name, len(evicted), usage, left, estimate, a.Quota)
+
+	// 删除备份记录与写入淘汰记录在同一个事务中 淘汰记录不会丢失
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		for _, r := range evicted {
+			log.Printf("[配额] 存档[ %s ]淘汰快照 %s (%s) 备份时新增 %d 字节\n",
+				a.Name, r.SnapShot, r.CreatedAt.Format("2006-01-02 15:04:05"), r.DataAdded)
+
+			if err := tx.Delete(&database.BackupRecord{}, r.ID).Error; err != nil {
+				return fmt.Errorf("删除备份记录失败: %w", err)
+			}
+
+			err := tx.Create(&database.QuotaEviction{
+				ArchiveID:  a.ID,
+				SnapShot:   r.SnapShot,
+				Comment:    r.Comment,
+				BackupAt:   r.CreatedAt,
+				DataAdded:  r.DataAdded,
+				Usage:      usage,
+				UsageAfter: left,
+				Estimate:   estimate,
+				Quota:      a.Quota,
+			}).Error
+			if err != nil {
+				return fmt.Errorf("写入淘汰记录失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return evicted, err
+	}
+
+	return evicted, nil
+}
+
+// archiveUsage 一组快照在仓库中实际占用的空间
+func archiveUsage(snapshots []string) (int64, error) {
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+
+	data, err := ResticRawData(snapshots...)
+	if err != nil {
+		return 0, err
+	}
+	return int64(data.TotalSize), nil
+}
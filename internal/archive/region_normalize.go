@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"fmt"
+	"io/fs"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	etc "minecraft-archive-backup/pkg/etc/core"
+	"minecraft-archive-backup/pkg/region"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// normalizeFolders 需要规范化的区域文件所在的文件夹
+var normalizeFolders = []string{world.RegionDir, world.EntitiesDir, world.PoiDir}
+
+// CanNormalizeRegions 存档类型是否包含区域文件
+func CanNormalizeRegions(a *database.Archive) bool {
+	var t = NormalizeArchiveType(a.Type)
+	return t == database.ArchiveTypeWorld || t == database.ArchiveTypeServer
+}
+
+// stagingDir 存档暂存副本的目录 每次备份使用相同的路径 便于 restic 按路径匹配父快照
+func stagingDir(a *database.Archive) string {
+	return filepath.Join(etc.DataDir, "staging", strconv.FormatUint(uint64(a.ID), 10))
+}
+
+// stageNormalized 将存档复制到暂存目录 并将其中的区域文件按区块顺序重新排列、清零未使用的扇区
+// 返回暂存的路径 (与 BackupPaths 一一对应 文件夹名称相同 回档时按名称对应) 存档本身不会被修改
+func stageNormalized(a *database.Archive) ([]string, error) {
+	var dir = stagingDir(a)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("清理暂存目录失败: %w", err)
+	}
+
+	var roots []string
+	for i, root := range a.BackupPaths() {
+		var target = filepath.Join(dir, strconv.Itoa(i), filepath.Base(root))
+		if err := stageTree(root, target); err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, fmt.Errorf("复制存档到暂存目录失败: %w", err)
+		}
+		roots = append(roots, target)
+	}
+	return roots, nil
+}
+
+// removeStaging 删除暂存的副本
+func removeStaging(a *database.Archive) {
+	_ = os.RemoveAll(stagingDir(a))
+}
+
+// stageTree 复制整个目录 区域文件写入规范化后的内容 所有文件保留原来的修改时间
+func stageTree(source, target string) error {
+	return filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(source, p)
+		if err != nil {
+			return err
+		}
+		var dst = filepath.Join(target, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(dst, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		_, _, isRegion := region.ParseFileName(d.Name())
+		if isRegion && slices.Contains(normalizeFolders, filepath.Base(filepath.Dir(p))) {
+			err = normalizeRegionFile(p, dst)
+		} else {
+			_, err = copyFile(p, dst)
+		}
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(dst, info.ModTime(), info.ModTime())
+	})
+}
+
+// normalizeRegionFile 重新排列区域文件 无法解析的文件原样复制 交由游戏自行处理
+func normalizeRegionFile(source, target string) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+
+	file, err := region.Parse(data)
+	if err != nil || len(data) == 0 {
+		return os.WriteFile(target, data, 0644)
+	}
+	normalized, err := file.Bytes()
+	if err != nil {
+		return os.WriteFile(target, data, 0644)
+	}
+	return os.WriteFile(target, normalized, 0644)
+}
@@ -1,17 +1,107 @@
 package archive
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"minecraft-archive-backup/model/dto/database"
 	"os/exec"
+	"slices"
+	"time"
 )
 
-func ResticBackup(archive *database.Archive) <-chan *BackupMessage {
-	cmd := NewResticCmd(exec.Command("restic", "backup", archive.Path,
+// ResticBackup 备份存档
+// parent 为空时由 restic 自行按主机名和路径挑选父快照
+func ResticBackup(archive *database.Archive, parent string) <-chan *BackupMessage {
+	return resticBackup(archive, archive.BackupPaths(), parent)
+}
+
+// resticBackup 备份指定的路径 规范化区域文件时为暂存的副本
+func resticBackup(archive *database.Archive, roots []string, parent string) <-chan *BackupMessage {
+	return resticBackupAt(archive, roots, parent, time.Time{})
+}
+
+// resticBackupAt 备份指定的路径 并将快照时间记为 at (为零时使用当前时间) 用于导入旧的备份
+func resticBackupAt(archive *database.Archive, roots []string, parent string, at time.Time) <-chan *BackupMessage {
+	args, err := backupArgs(archive, roots, parent, at)
+	if err != nil {
+		return errorMessageChan(err.Error())
+	}
+
+	cmd := NewResticCmd(exec.Command("restic", args...))
+
+	return executeResticCommand(cmd)
+}
+
+// ResticBackupDryRun 模拟一次备份 返回 restic 的摘要信息 用于估算新增的数据量
+func ResticBackupDryRun(archive *database.Archive, parent string) (*BackupMessage, error) {
+	return backupDryRun(archive, archive.BackupPaths(), parent)
+}
+
+// backupDryRun 模拟备份指定的路径
+func backupDryRun(archive *database.Archive, roots []string, parent string) (*BackupMessage, error) {
+	args, err := backupArgs(archive, roots, parent, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	args = append(args, "--dry-run")
+	cmd := NewResticCmd(exec.Command("restic", args...))
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("模拟备份失败: %v", err)
+	}
+
+	// 逐行查找 summary 消息
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		var msg BackupMessage
+		if json.Unmarshal(scanner.Bytes(), &msg) == nil && msg.MessageType == "summary" {
+			return &msg, nil
+		}
+	}
+
+	return nil, fmt.Errorf("模拟备份没有返回摘要信息")
+}
+
+// backupArgs 构建备份命令的参数
+func backupArgs(archive *database.Archive, roots []string, parent string, at time.Time) ([]string, error) {
+	// 所有路径写入同一个快照 保证服务器各个维度与插件数据的一致性
+	args := append([]string{"backup"}, roots...)
+	args = append(args,
 		"--json",
 		"--use-fs-snapshot",
 		"-o", "vss.timeout=30s",
-		//"--skip-if-unchanged",
-	))
+		"--host", ResticHost(),
+		// 存档没有变化时不创建新的快照 摘要中不会带有快照ID
+		"--skip-if-unchanged",
+	)
 
-	return executeResticCommand(cmd)
+	// 暂存的副本每次都会重新创建 只按大小与修改时间判断文件是否变化
+	if !slices.Equal(roots, archive.BackupPaths()) {
+		args = append(args, "--ignore-inode", "--ignore-ctime")
+	}
+
+	// 显式指定父快照 存档路径变化后仍然可以增量读取
+	if parent != "" {
+		args = append(args, "--parent", parent)
+	}
+
+	// 导入的备份使用原来的时间 restic 按本地时间解析
+	if !at.IsZero() {
+		args = append(args, "--time", at.Local().Format(time.DateTime))
+	}
+
+	// 存档的排除规则 Windows 的路径不区分大小写
+	excludeFile, err := writeExcludeFile(archive, roots)
+	if err != nil {
+		return nil, err
+	}
+	if excludeFile != "" {
+		args = append(args, "--iexclude-file", excludeFile)
+	}
+
+	return args, nil
 }
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"minecraft-archive-backup/model/dto/database"
 	etc "minecraft-archive-backup/pkg/etc/core"
+	"minecraft-archive-backup/pkg/etc/model"
 	etcRun "minecraft-archive-backup/pkg/etc/run"
 	"os"
 	"os/exec"
@@ -17,20 +19,24 @@ import (
 var resticConfig, _ = etcRun.LoadVipers("Restic")
 
 func NewResticCmd(cmd *exec.Cmd) *exec.Cmd {
-	resticConfig.Mu.RLock()
-	defer resticConfig.Mu.RUnlock()
+	return newResticCmdWithRepo(cmd, ResticRepoPath())
+}
 
-	cmd.Path = filepath.Join(etc.BinDir, "restic.exe")
+// newResticCmdWithRepo 使用指定的仓库路径构建 restic 命令
+func newResticCmdWithRepo(cmd *exec.Cmd, repoPath string) *exec.Cmd {
+	return newResticCmdWithAuth(cmd, repoPath, ResticPassword())
+}
 
-	// 创建目录
-	repoPath := filepath.Join(etc.DataDir, "/restic/repo")
-	cachePath := filepath.Join(etc.DataDir, "/restic/cache")
+// newResticCmdWithAuth 使用指定的仓库路径与密码构建 restic 命令
+// 用于打开其他电脑上创建的仓库 例如在新安装的程序中恢复程序数据
+func newResticCmdWithAuth(cmd *exec.Cmd, repoPath, password string) *exec.Cmd {
+	cmd.Path = filepath.Join(etc.BinDir, "restic.exe")
 
 	// 设置环境变量
 	cmd.Env = []string{
 		fmt.Sprintf("RESTIC_REPOSITORY=%s", repoPath),
-		fmt.Sprintf("RESTIC_PASSWORD=%s", resticConfig.V.GetString("password")),
-		fmt.Sprintf("RESTIC_CACHE_DIR=%s", cachePath),
+		fmt.Sprintf("RESTIC_PASSWORD=%s", password),
+		fmt.Sprintf("RESTIC_CACHE_DIR=%s", resticCachePath()),
 	}
 
 	// 静默隐藏窗口
@@ -40,6 +46,45 @@ func NewResticCmd(cmd *exec.Cmd) *exec.Cmd {
 	return cmd
 }
 
+// DefaultResticRepoPath 默认的仓库路径 位于执行程序旁的 data 目录
+func DefaultResticRepoPath() string {
+	return filepath.Join(etc.DataDir, "/restic/repo")
+}
+
+// ResticRepoPath 当前使用的仓库路径 未配置时使用默认路径
+func ResticRepoPath() string {
+	resticConfig.Mu.RLock()
+	defer resticConfig.Mu.RUnlock()
+
+	if repoPath := resticConfig.V.GetString("repository"); repoPath != "" {
+		return repoPath
+	}
+	return DefaultResticRepoPath()
+}
+
+// ResticPassword 当前仓库的密码
+func ResticPassword() string {
+	resticConfig.Mu.RLock()
+	defer resticConfig.Mu.RUnlock()
+	return resticConfig.V.GetString("password")
+}
+
+// ResticHost 备份时使用的固定主机名
+func ResticHost() string {
+	resticConfig.Mu.RLock()
+	defer resticConfig.Mu.RUnlock()
+
+	if host := resticConfig.V.GetString("host"); host != "" {
+		return host
+	}
+	return model.DefaultHost
+}
+
+// resticCachePath 缓存目录 迁移仓库时不会跟随移动
+func resticCachePath() string {
+	return filepath.Join(etc.DataDir, "/restic/cache")
+}
+
 // init 初始化 创建仓库和缓存目录
 func init() {
 	// 1. 检查 Restic 可执行文件是否存在
@@ -50,8 +95,8 @@ func init() {
 	}
 
 	// 2. 创建仓库目录和缓存目录
-	repoPath := filepath.Join(etc.DataDir, "/restic/repo")
-	cachePath := filepath.Join(etc.DataDir, "/restic/cache")
+	repoPath := ResticRepoPath()
+	cachePath := resticCachePath()
 
 	// 创建目录
 	if err := os.MkdirAll(repoPath, 0755); err != nil {
@@ -133,6 +178,30 @@ type BackupMessage struct {
 	BackupStart string `json:"backup_start,omitempty"`
 	BackupEnd   string `json:"backup_end,omitempty"`
 	SnapshotID  string `json:"snapshot_id,omitempty"`
+
+	// Normalized 备份前是否规范化了区域文件 不是 restic 的输出
+	Normalized bool `json:"-"`
+
+	// Health 备份前检查存档完整性的结果 不是 restic 的输出
+	Health       database.HealthStatus `json:"-"`
+	HealthReport string                `json:"-"`
+}
+
+// Skipped 备份是否因为存档没有变化而被跳过 (--skip-if-unchanged 的摘要中没有快照ID)
+func (m *BackupMessage) Skipped() bool {
+	return m != nil && m.MessageType == "summary" && m.SnapshotID == ""
+}
+
+// errorMessageChan 返回只包含一条错误消息的通道 用于命令无法启动的情况
+func errorMessageChan(message string) <-chan *BackupMessage {
+	outputChan := make(chan *BackupMessage, 1)
+	outputChan <- &BackupMessage{
+		MessageType: "error",
+		Message:     message,
+		Code:        1,
+	}
+	close(outputChan)
+	return outputChan
 }
 
 // 通用的执行函数
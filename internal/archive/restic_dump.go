@@ -0,0 +1,102 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ErrNotInSnapshot 快照中没有要读取的文件
+var ErrNotInSnapshot = errors.New("快照中没有该文件")
+
+// LsNode restic ls 输出的文件信息
+type LsNode struct {
+	Name  string    `json:"name"`
+	Type  string    `json:"type"` // file / dir / symlink
+	Path  string    `json:"path"` // 快照中的路径 例如 /C/Users/.../region/r.0.0.mca
+	Size  int64     `json:"size"`
+	MTime time.Time `json:"mtime"`
+}
+
+// ResticLs 列出快照中的所有文件
+func ResticLs(snapshot string) ([]*LsNode, error) {
+	cmd := NewResticCmd(exec.Command("restic", "ls", "--json", snapshot))
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("列出快照文件失败: %v", err)
+	}
+
+	// 第一行是快照信息 之后每行一个文件
+	var nodes []*LsNode
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var node LsNode
+		if json.Unmarshal(scanner.Bytes(), &node) == nil && node.Type != "" && node.Path != "" {
+			nodes = append(nodes, &node)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("解析快照文件列表失败: %w", err)
+	}
+
+	return nodes, nil
+}
+
+// ResticDump 将快照中的单个文件写入 w
+func ResticDump(snapshot, path string, w io.Writer) error {
+	cmd := NewResticCmd(exec.Command("restic", "dump", snapshot, path))
+
+	var stderr strings.Builder
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "not found") {
+			return fmt.Errorf("%w: %s", ErrNotInSnapshot, path)
+		}
+		return fmt.Errorf("读取快照中的文件失败: %v\n输出: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// ResticDumpHead 只读取快照中文件开头的 n 个字节 读够之后立即结束 restic
+// 文件不足 n 个字节时返回整个文件
+func ResticDumpHead(snapshot, path string, n int) ([]byte, error) {
+	cmd := NewResticCmd(exec.Command("restic", "dump", snapshot, path))
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("无法启动命令: %v", err)
+	}
+
+	var data = make([]byte, n)
+	read, readErr := io.ReadFull(stdout, data)
+	if readErr == nil {
+		// 已经读够 不需要剩余的内容
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return data, nil
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("读取快照中的文件失败: %v\n输出: %s", err, stderr.String())
+	}
+	if readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+		return nil, readErr
+	}
+	return data[:read], nil
+}
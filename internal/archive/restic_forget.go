@@ -2,15 +2,33 @@ package archive
 
 import (
 	"fmt"
+	"minecraft-archive-backup/model/dto/database"
 	"os/exec"
 )
 
 // ResticForget 删除指定的快照
 func ResticForget(snapshots ...string) error {
+	repoMu.RLock()
+	defer repoMu.RUnlock()
+	return resticForget(snapshots...)
+}
+
+// resticForget 删除指定的快照 调用方需要持有 repoMu
+func resticForget(snapshots ...string) error {
 	if len(snapshots) == 0 {
 		return nil
 	}
 
+	// 固定的快照不允许删除
+	var pinned int64
+	result := DB.Model(&database.BackupRecord{}).Where("pinned = ? AND snap_shot IN ?", true, snapshots).Count(&pinned)
+	if result.Error != nil {
+		return fmt.Errorf("查询快照固定状态失败: %w", result.Error)
+	}
+	if pinned > 0 {
+		return ErrSnapshotPinned
+	}
+
 	// 构建命令参数
 	args := []string{"forget"}
 	args = append(args, "--json")
@@ -25,5 +43,8 @@ func ResticForget(snapshots ...string) error {
 		return fmt.Errorf("删除快照失败: %v\n输出: %s", err, output)
 	}
 
+	// 快照已删除 预览图不再需要
+	removeMapPreviews(snapshots...)
+
 	return nil
 }
@@ -19,7 +19,7 @@ type RawData struct {
 }
 
 // ResticRawData 快照实际占用的大小
-func ResticRawData(snapshots string) (*RawData, error) {
+func ResticRawData(snapshots ...string) (*RawData, error) {
 	var data = &RawData{}
 
 	if len(snapshots) == 0 {
@@ -30,6 +30,7 @@ func ResticRawData(snapshots string) (*RawData, error) {
 	args := []string{"stats"}
 	args = append(args, "--json")
 	args = append(args, "--mode", "raw-data")
+	args = append(args, snapshots...)
 
 	cmd := NewResticCmd(exec.Command("restic", args...))
 
@@ -52,7 +53,7 @@ type RestoreSize struct {
 }
 
 // ResticRestoreSize 增量备份 和 压缩后 占用的空间
-func ResticRestoreSize(snapshots string) (*RestoreSize, error) {
+func ResticRestoreSize(snapshots ...string) (*RestoreSize, error) {
 	var data = &RestoreSize{}
 
 	if len(snapshots) == 0 {
@@ -63,6 +64,7 @@ func ResticRestoreSize(snapshots string) (*RestoreSize, error) {
 	args := []string{"stats"}
 	args = append(args, "--json")
 	args = append(args, "--mode", "restore-size")
+	args = append(args, snapshots...)
 
 	cmd := NewResticCmd(exec.Command("restic", args...))
 
@@ -102,8 +104,29 @@ type SnapshotMessage struct {
 		TotalFilesProcessed int       `json:"total_files_processed"`
 		TotalBytesProcessed int       `json:"total_bytes_processed"`
 	} `json:"summary"`
-	Id      string `json:"id"`
-	ShortId string `json:"short_id"`
+	Tags     []string `json:"tags"`
+	Original string   `json:"original"` // 快照被重写(例如修改标签)前的原始ID
+	Id       string   `json:"id"`
+	ShortId  string   `json:"short_id"`
+}
+
+// ResticSnapshots 获取仓库中的所有快照
+func ResticSnapshots(tags ...string) ([]*SnapshotMessage, error) {
+	var result []*SnapshotMessage
+
+	args := []string{"snapshots", "--json"}
+	for _, tag := range tags {
+		args = append(args, "--tag", tag)
+	}
+	cmd := NewResticCmd(exec.Command("restic", args...))
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("获取快照列表失败: %v", err)
+	}
+
+	err = json.Unmarshal(output, &result)
+	return result, err
 }
 
 // ResticSnapshotInfo 获取快照的详细信息
@@ -120,6 +143,9 @@ func ResticSnapshotInfo(snapshotID string) (*SnapshotMessage, error) {
 	}
 
 	_ = json.Unmarshal(output, &result)
+	if len(result) == 0 {
+		return nil, fmt.Errorf("快照 %s 不存在", snapshotID)
+	}
 
 	return result[0], nil
 }
@@ -0,0 +1,262 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// repoMu 迁移仓库时独占 备份、导入、恢复、固定与删除快照共享
+var repoMu sync.RWMutex
+
+// MigrateRepository 将仓库复制到新的路径 校验通过后切换配置
+// move == true 时 旧仓库会被记录下来 等待用户确认后再删除 (ConfirmRepositoryMigration)
+// move == false 时 旧仓库作为一份独立的副本保留
+func MigrateRepository(target string, move bool) <-chan *BackupMessage {
+	outputChan := make(chan *BackupMessage, 100)
+
+	go func() {
+		defer close(outputChan)
+
+		// 等待正在进行的备份、恢复等操作结束 迁移期间不允许新的操作
+		outputChan <- &BackupMessage{MessageType: "info", Message: "正在等待其他仓库操作完成..."}
+		repoMu.Lock()
+		defer repoMu.Unlock()
+
+		var source = ResticRepoPath()
+
+		target, existed, err := checkMigrateTarget(source, target)
+		if err != nil {
+			outputChan <- &BackupMessage{MessageType: "error", Message: err.Error(), Code: 1}
+			return
+		}
+
+		// 1. 复制仓库文件
+		if err := copyRepository(source, target, outputChan); err != nil {
+			removeMigrateTarget(target, existed)
+			outputChan <- &BackupMessage{
+				MessageType: "error",
+				Message:     fmt.Sprintf("复制仓库失败: %v", err),
+				Code:        1,
+			}
+			return
+		}
+
+		// 2. 使用 restic check 校验新仓库
+		outputChan <- &BackupMessage{MessageType: "info", Message: "正在校验新仓库...", PercentDone: 0.96}
+		checkCmd := newResticCmdWithRepo(exec.Command("restic", "check"), target)
+		if output, err := checkCmd.CombinedOutput(); err != nil {
+			removeMigrateTarget(target, existed)
+			outputChan <- &BackupMessage{
+				MessageType: "error",
+				Message:     fmt.Sprintf("新仓库校验失败，配置未切换: %v\n输出: %s", err, output),
+				Code:        1,
+			}
+			return
+		}
+
+		// 3. 原子切换配置
+		var values = map[string]any{"repository": target}
+		if move {
+			values["old_repository"] = source
+		}
+		if err := resticConfig.SaveAtomic(values); err != nil {
+			outputChan <- &BackupMessage{
+				MessageType: "error",
+				Message:     fmt.Sprintf("切换仓库配置失败: %v", err),
+				Code:        1,
+			}
+			return
+		}
+
+		outputChan <- &BackupMessage{
+			MessageType: "done",
+			Message:     fmt.Sprintf("仓库已迁移到 %s", target),
+		}
+	}()
+
+	return outputChan
+}
+
+// PendingOldRepository 迁移后等待确认删除的旧仓库 没有则返回空字符串
+func PendingOldRepository() string {
+	resticConfig.Mu.RLock()
+	defer resticConfig.Mu.RUnlock()
+	return resticConfig.V.GetString("old_repository")
+}
+
+// ConfirmRepositoryMigration 用户确认新仓库可用后 删除旧仓库
+func ConfirmRepositoryMigration() error {
+	var oldRepo = PendingOldRepository()
+	if oldRepo == "" {
+		return nil
+	}
+
+	if samePath(oldRepo, ResticRepoPath()) {
+		return errors.New("旧仓库与当前仓库为同一路径，拒绝删除")
+	}
+
+	if err := os.RemoveAll(oldRepo); err != nil {
+		return fmt.Errorf("删除旧仓库失败: %w", err)
+	}
+
+	return resticConfig.SaveAtomic(map[string]any{"old_repository": ""})
+}
+
+// checkMigrateTarget 检查迁移目标 目标必须不存在或为空目录 且不能位于旧仓库内部
+// existed 表示目标文件夹在迁移前已经存在
+func checkMigrateTarget(source, target string) (abs string, existed bool, err error) {
+	if strings.TrimSpace(target) == "" {
+		return "", false, errors.New("目标路径不能为空")
+	}
+
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return "", false, fmt.Errorf("无法解析目标路径: %w", err)
+	}
+
+	if samePath(source, target) {
+		return "", false, errors.New("目标路径与当前仓库相同")
+	}
+
+	if rel, err := filepath.Rel(source, target); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false, errors.New("目标路径不能位于当前仓库内部")
+	}
+
+	entries, err := os.ReadDir(target)
+	switch {
+	case os.IsNotExist(err):
+		return target, false, nil
+	case err != nil:
+		return "", false, fmt.Errorf("无法读取目标路径: %w", err)
+	case len(entries) > 0:
+		return "", false, errors.New("目标文件夹不为空")
+	}
+
+	return target, true, nil
+}
+
+// removeMigrateTarget 迁移失败时清理复制了一半的目标
+// 目标文件夹是本次迁移创建的则整体删除 否则只删除其中的内容 (迁移前它是空的)
+func removeMigrateTarget(target string, existed bool) {
+	if !existed {
+		if err := os.RemoveAll(target); err != nil {
+			log.Printf("[迁移仓库] 清理目标文件夹失败: %v\n", err)
+		}
+		return
+	}
+
+	entries, err := os.ReadDir(target)
+	if err != nil {
+		log.Printf("[迁移仓库] 清理目标文件夹失败: %v\n", err)
+		return
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(target, entry.Name())); err != nil {
+			log.Printf("[迁移仓库] 清理目标文件夹失败: %v\n", err)
+		}
+	}
+}
+
+// copyRepository 复制仓库内的所有文件 并通过通道汇报进度
+func copyRepository(source, target string, outputChan chan<- *BackupMessage) error {
+	// 统计文件数量与大小
+	var totalFiles int
+	var totalBytes int64
+	err := filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			totalFiles++
+			totalBytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var filesDone int
+	var bytesDone int64
+	return filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(target, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(dst, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		written, err := copyFile(path, dst)
+		if err != nil {
+			return err
+		}
+
+		filesDone++
+		bytesDone += written
+
+		var percent float64
+		if totalBytes > 0 {
+			// 预留最后的部分给校验阶段
+			percent = float64(bytesDone) / float64(totalBytes) * 0.95
+		}
+		outputChan <- &BackupMessage{
+			MessageType: "status",
+			PercentDone: percent,
+			TotalFiles:  totalFiles,
+			FilesDone:   filesDone,
+			TotalBytes:  totalBytes,
+			BytesDone:   bytesDone,
+		}
+		return nil
+	})
+}
+
+// copyFile 复制单个文件 并同步到磁盘
+func copyFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	written, err := io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return written, err
+}
+
+// samePath 判断两个路径是否指向同一位置 (Windows 下不区分大小写)
+func samePath(a, b string) bool {
+	return strings.EqualFold(filepath.Clean(a), filepath.Clean(b))
+}
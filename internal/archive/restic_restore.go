@@ -1,27 +1,126 @@
 package archive
 
 import (
+	"errors"
 	"fmt"
 	"minecraft-archive-backup/model/dto/database"
 	"os/exec"
+	"path"
+	"path/filepath"
 	"strings"
 )
 
 // ResticRestore 回档到指定的快照id时
+// 快照中的每个路径分别恢复到存档中对应的路径 全部完成后才发送完成消息
 func ResticRestore(archive *database.Archive, record *database.BackupRecord) <-chan *BackupMessage {
 	// 查询当前快照的备份路径
-	var info, _ = ResticSnapshotInfo(record.SnapShot)
+	info, err := ResticSnapshotInfo(record.SnapShot)
+	if err != nil {
+		return errorMessageChan(fmt.Sprintf("查询快照信息失败: %v", err))
+	}
+
+	targets, err := restoreTargets(info.Paths, archive.BackupPaths())
+	if err != nil {
+		return errorMessageChan(err.Error())
+	}
+
+	outputChan := make(chan *BackupMessage, 100)
+
+	go func() {
+		defer close(outputChan)
+
+		repoMu.RLock()
+		defer repoMu.RUnlock()
+
+		var total = float64(len(info.Paths))
+		for i, snapshotPath := range info.Paths {
+			// 构建命令参数
+			args := []string{"restore", "--json"}
+			args = append(args, fmt.Sprintf("%s:%s",
+				record.SnapShot,
+				ConvertWindowsToUnixPath(snapshotPath)))
+			args = append(args, "--target", targets[i])
 
-	// 构建命令参数
-	args := []string{"restore", "--json"}
-	args = append(args, fmt.Sprintf("%s:%s",
-		record.SnapShot,
-		ConvertWindowsToUnixPath(info.Paths[0])))
-	args = append(args, "--target", archive.Path)
+			if len(info.Paths) > 1 {
+				outputChan <- &BackupMessage{
+					MessageType: "info",
+					Message:     fmt.Sprintf("正在恢复 (%d/%d): %s", i+1, len(info.Paths), targets[i]),
+					PercentDone: float64(i) / total,
+				}
+			}
 
-	cmd := NewResticCmd(exec.Command("restic", args...))
+			cmd := NewResticCmd(exec.Command("restic", args...))
+
+			for msg := range executeResticCommand(cmd) {
+				// 中间路径的摘要与完成消息不转发 避免进度窗口提前结束
+				if msg.MessageType == "summary" || msg.MessageType == "done" {
+					continue
+				}
+				if msg.Code != 0 || (msg.MessageType == "error" && msg.Message != "") {
+					outputChan <- msg
+					return
+				}
+				// 按路径的数量换算整体进度
+				if msg.PercentDone > 0 {
+					msg.PercentDone = (float64(i) + msg.PercentDone) / total
+				}
+				outputChan <- msg
+			}
+		}
+
+		outputChan <- &BackupMessage{
+			MessageType: "done",
+			Message:     fmt.Sprintf("已恢复 %d 个路径", len(info.Paths)),
+			PercentDone: 1,
+		}
+	}()
+
+	return outputChan
+}
+
+// restoreTargets 为快照中的每个路径找到恢复的目标路径
+// 优先匹配完全相同的路径 其次匹配文件夹名称 只有一个路径时直接使用存档的主路径 (存档被移动过)
+func restoreTargets(snapshotPaths, archivePaths []string) ([]string, error) {
+	if len(snapshotPaths) == 0 {
+		return nil, errors.New("快照中没有备份路径")
+	}
+	if len(snapshotPaths) == 1 && len(archivePaths) == 1 {
+		return archivePaths, nil
+	}
+
+	var targets = make([]string, len(snapshotPaths))
+	var used = make([]bool, len(archivePaths))
+
+	// 1. 路径完全相同
+	for i, snapshotPath := range snapshotPaths {
+		for j, archivePath := range archivePaths {
+			if !used[j] && samePath(snapshotPath, archivePath) {
+				targets[i] = archivePath
+				used[j] = true
+				break
+			}
+		}
+	}
+
+	// 2. 文件夹名称相同
+	for i, snapshotPath := range snapshotPaths {
+		if targets[i] != "" {
+			continue
+		}
+		var name = path.Base(ConvertWindowsToUnixPath(snapshotPath))
+		for j, archivePath := range archivePaths {
+			if !used[j] && strings.EqualFold(filepath.Base(archivePath), name) {
+				targets[i] = archivePath
+				used[j] = true
+				break
+			}
+		}
+		if targets[i] == "" {
+			return nil, fmt.Errorf("快照中的路径 %s 在存档中没有对应的路径，请检查存档的附加路径", snapshotPath)
+		}
+	}
 
-	return executeResticCommand(cmd)
+	return targets, nil
 }
 
 // ConvertWindowsToUnixPath 将Windows路径转换为Unix格式
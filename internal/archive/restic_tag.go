@@ -0,0 +1,62 @@
+package archive
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// PinnedTag 固定快照使用的 restic 标签
+// 在 restic 中执行 forget --keep-tag pinned 时 这些快照会被保留
+const PinnedTag = "pinned"
+
+// ResticTag 为快照添加或移除标签
+// restic 修改标签时会重写快照 因此返回重写后的新快照ID
+func ResticTag(snapshot string, add bool, tags ...string) (string, error) {
+	if len(tags) == 0 {
+		return snapshot, nil
+	}
+
+	// 记录快照最初的ID 用于在重写后找到新的快照
+	info, err := ResticSnapshotInfo(snapshot)
+	if err != nil || info == nil {
+		return "", fmt.Errorf("查询快照信息失败: %v", err)
+	}
+	var original = info.Original
+	if original == "" {
+		original = info.Id
+	}
+
+	// 构建命令参数
+	var flag = "--add"
+	if !add {
+		flag = "--remove"
+	}
+	args := []string{"tag"}
+	for _, tag := range tags {
+		args = append(args, flag, tag)
+	}
+	args = append(args, snapshot)
+
+	cmd := NewResticCmd(exec.Command("restic", args...))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("修改快照标签失败: %v\n输出: %s", err, output)
+	}
+
+	// 找到重写后的快照 标签本来就存在时 快照不会被重写
+	snapshots, err := ResticSnapshots()
+	if err != nil {
+		return "", err
+	}
+	for _, s := range snapshots {
+		if s.Id == info.Id {
+			return s.Id, nil
+		}
+	}
+	for _, s := range snapshots {
+		if s.Original == original {
+			return s.Id, nil
+		}
+	}
+
+	return "", fmt.Errorf("修改标签后找不到快照 %s", snapshot)
+}
@@ -0,0 +1,211 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	"minecraft-archive-backup/pkg/zipcrypto"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+)
+
+// playerFolders 不导出玩家数据时跳过的文件夹
+var playerFolders = []string{world.PlayerDataDir, world.StatsDir, world.AdvancementsDir}
+
+// ExportOptions 导出快照的选项
+type ExportOptions struct {
+	Password       string // 为空时不加密 加密方式为 ZipCrypto
+	SkipPlayerData bool   // 不包含 playerdata、stats 与 advancements
+}
+
+// ExportSnapshot 将快照中的世界导出为 zip 压缩包的根目录为世界文件夹 可以直接放入 saves 或由启动器导入
+// 服务器只导出其中的世界 先写入临时文件 成功后再重命名为 target
+func ExportSnapshot(a *database.Archive, record *database.BackupRecord, target string, options ExportOptions) <-chan *BackupMessage {
+	outputChan := make(chan *BackupMessage, 100)
+
+	go func() {
+		defer close(outputChan)
+
+		var fail = func(err error) {
+			outputChan <- &BackupMessage{MessageType: "error", Message: err.Error(), Code: 1}
+		}
+
+		outputChan <- &BackupMessage{MessageType: "info", Message: "正在读取快照文件列表..."}
+		worldPath, err := snapshotWorldPath(a, record.SnapShot)
+		if err != nil {
+			fail(err)
+			return
+		}
+		nodes, err := ResticLs(record.SnapShot)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		// 统计需要导出的大小 用于计算进度
+		var total int64
+		for _, node := range nodes {
+			if rel, ok := strings.CutPrefix(node.Path, worldPath+"/"); ok && node.Type == "file" && exportFile(rel, options) {
+				total += node.Size
+			}
+		}
+
+		var temp = target + ".tmp"
+		written, err := writeExportZip(record.SnapShot, worldPath, temp, options, func(name string, done int64) {
+			var percent float64
+			if total > 0 {
+				percent = min(float64(done)/float64(total), 1)
+			}
+			outputChan <- &BackupMessage{MessageType: "info", PercentDone: percent, Message: name}
+		})
+		if err != nil {
+			_ = os.Remove(temp)
+			fail(err)
+			return
+		}
+		if err := os.Rename(temp, target); err != nil {
+			_ = os.Remove(temp)
+			fail(fmt.Errorf("保存压缩包失败: %w", err))
+			return
+		}
+
+		outputChan <- &BackupMessage{
+			MessageType: "done",
+			Message:     fmt.Sprintf("已导出 %d 个文件到 %s", written, target),
+			PercentDone: 1,
+		}
+	}()
+
+	return outputChan
+}
+
+// exportFile 世界中的文件是否需要导出 rel 为相对世界文件夹的路径
+func exportFile(rel string, options ExportOptions) bool {
+	var first, _, _ = strings.Cut(rel, "/")
+	if options.SkipPlayerData && slices.Contains(playerFolders, first) {
+		return false
+	}
+	return rel != "session.lock"
+}
+
+// writeExportZip 将 restic dump 输出的 tar 流转换为 zip 返回写入的文件数
+func writeExportZip(snapshot, worldPath, target string, options ExportOptions, onProgress func(name string, done int64)) (int, error) {
+	cmd := NewResticCmd(exec.Command("restic", "dump", "--archive", "tar", snapshot, worldPath))
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("无法启动命令: %v", err)
+	}
+
+	count, convertErr := convertTar(stdout, worldPath, target, options, onProgress)
+	if convertErr != nil {
+		// 提前结束时 restic 还在输出 需要结束进程
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return 0, convertErr
+	}
+	if err := cmd.Wait(); err != nil {
+		return 0, fmt.Errorf("读取快照失败: %v\n输出: %s", err, stderr.String())
+	}
+	return count, nil
+}
+
+// convertTar 读取 tar 流并写入 zip 世界文件夹作为压缩包的根目录
+func convertTar(r io.Reader, worldPath, target string, options ExportOptions, onProgress func(name string, done int64)) (int, error) {
+	file, err := os.Create(target)
+	if err != nil {
+		return 0, fmt.Errorf("创建压缩包失败: %w", err)
+	}
+	defer file.Close()
+
+	var zw = zip.NewWriter(file)
+	var root = path.Base(worldPath)
+	var count int
+	var done int64
+
+	var reader = tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("读取快照失败: %w", err)
+		}
+
+		var rel = exportRelPath(header.Name, worldPath)
+		if rel == "" || !exportFile(rel, options) {
+			continue
+		}
+		var name = root + "/" + rel
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if _, err := zw.CreateHeader(&zip.FileHeader{Name: name + "/", Modified: header.ModTime}); err != nil {
+				return 0, err
+			}
+			continue
+		case tar.TypeReg:
+		default:
+			continue
+		}
+
+		var fh = &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: header.ModTime}
+		var w io.Writer
+		var closer io.Closer
+		if options.Password != "" {
+			encrypted, err := zipcrypto.Create(zw, fh, options.Password)
+			if err != nil {
+				return 0, err
+			}
+			w, closer = encrypted, encrypted
+		} else if w, err = zw.CreateHeader(fh); err != nil {
+			return 0, err
+		}
+
+		n, err := io.Copy(w, reader)
+		if err != nil {
+			return 0, fmt.Errorf("写入 %s 失败: %w", rel, err)
+		}
+		if closer != nil {
+			if err := closer.Close(); err != nil {
+				return 0, err
+			}
+		}
+
+		count++
+		done += n
+		onProgress(rel, done)
+	}
+
+	if err := zw.Close(); err != nil {
+		return 0, fmt.Errorf("写入压缩包失败: %w", err)
+	}
+	return count, file.Close()
+}
+
+// exportRelPath tar 中的路径相对世界文件夹的部分 世界文件夹本身返回空字符串
+// 不同版本的 restic 输出完整路径或相对所导出目录的路径
+func exportRelPath(name, worldPath string) string {
+	name = strings.Trim(name, "/")
+	for _, prefix := range []string{strings.Trim(worldPath, "/"), path.Base(worldPath)} {
+		if name == prefix {
+			return ""
+		}
+		if rel, ok := strings.CutPrefix(name, prefix+"/"); ok {
+			return rel
+		}
+	}
+	return name
+}
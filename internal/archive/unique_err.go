@@ -7,8 +7,9 @@ import (
 
 // FieldNameToChinese 字段名到中文名的映射
 var FieldNameToChinese = map[string]string{
-	"archives.name": "存档名称",
-	"archives.path": "存档路径",
+	"archives.name":      "存档名称",
+	"archives.path":      "存档路径",
+	"archive_paths.path": "附加路径",
 }
 
 // WrapUniqueConstraintError 包装唯一约束错误，返回友好的中文错误
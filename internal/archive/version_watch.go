@@ -0,0 +1,206 @@
+package archive
+
+import (
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"log"
+	"minecraft-archive-backup/internal/world"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// watchedWorld 正在监听的世界 记录最后一次读取到的游戏版本
+type watchedWorld struct {
+	archiveID   uint
+	dataVersion int
+	versionName string
+}
+
+// versionWatcher 监听存档的 level.dat 游戏版本升级时固定升级前的最后一个快照
+type versionWatcher struct {
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	worlds  map[string]*watchedWorld // 世界路径 -> 状态
+}
+
+var gameVersions versionWatcher
+
+// WatchGameVersions 按数据库中的存档同步监听的世界 首次调用时启动监听
+// 新增、删除存档或修改路径后 再次调用即可生效
+func WatchGameVersions() error {
+	gameVersions.mu.Lock()
+	defer gameVersions.mu.Unlock()
+
+	if gameVersions.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("创建文件监听失败: %w", err)
+		}
+		gameVersions.watcher = watcher
+		gameVersions.worlds = make(map[string]*watchedWorld)
+		go gameVersions.run(watcher)
+	}
+
+	archives, err := GetAllArchives()
+	if err != nil {
+		return fmt.Errorf("查询存档失败: %w", err)
+	}
+
+	var current = make(map[string]bool)
+	for i := range archives {
+		var worldPath = WorldPath(&archives[i])
+		if worldPath == "" {
+			continue
+		}
+		worldPath = filepath.Clean(worldPath)
+		current[worldPath] = true
+		if _, ok := gameVersions.worlds[worldPath]; ok {
+			continue
+		}
+
+		// level.dat 会先写入 level.dat_new 再重命名 因此监听整个世界目录
+		if err := gameVersions.watcher.Add(worldPath); err != nil {
+			log.Printf("[版本监听] 存档[ %s ]无法监听: %v\n", archives[i].Name, err)
+			continue
+		}
+		var state = &watchedWorld{archiveID: archives[i].ID}
+		state.dataVersion, state.versionName, _ = readGameVersion(&archives[i])
+		gameVersions.worlds[worldPath] = state
+
+		// 程序未运行期间 存档已被新版本的游戏打开过
+		if latest, _ := GetLatestBackupRecordByArchiveID(archives[i].ID); latest != nil &&
+			latest.DataVersion != 0 && latest.DataVersion < state.dataVersion {
+			go onGameUpgraded(archives[i].ID, latest.DataVersion, latest.VersionName)
+		}
+	}
+
+	for worldPath := range gameVersions.worlds {
+		if !current[worldPath] {
+			gameVersions.unwatch(worldPath)
+		}
+	}
+	return nil
+}
+
+// resyncGameVersions 新增、删除或修改存档后立即同步监听的世界 监听没有启动时不做任何事
+func resyncGameVersions() {
+	gameVersions.mu.Lock()
+	var started = gameVersions.watcher != nil
+	gameVersions.mu.Unlock()
+
+	if started {
+		if err := WatchGameVersions(); err != nil {
+			log.Printf("[版本监听] %v\n", err)
+		}
+	}
+}
+
+// StopWatchGameVersions 停止监听 之后再调用 WatchGameVersions 会重新开始
+func StopWatchGameVersions() {
+	gameVersions.mu.Lock()
+	defer gameVersions.mu.Unlock()
+
+	if gameVersions.watcher != nil {
+		_ = gameVersions.watcher.Close()
+		gameVersions.watcher = nil
+		gameVersions.worlds = nil
+	}
+}
+
+// unwatch 停止监听一个世界 调用时需要持有 mu
+func (w *versionWatcher) unwatch(worldPath string) {
+	if w.watcher != nil {
+		_ = w.watcher.Remove(worldPath)
+	}
+	delete(w.worlds, worldPath)
+}
+
+// run 处理文件事件 同一时间只处理一个事件
+func (w *versionWatcher) run(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Base(event.Name) != world.LevelDatName || !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			w.check(filepath.Dir(event.Name))
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[版本监听] %v\n", err)
+		}
+	}
+}
+
+// check 重新读取世界的游戏版本 版本升高且世界正在被使用时 视为游戏升级了存档
+// 回档等本程序自己的写入发生在世界未被使用时 不会触发
+func (w *versionWatcher) check(worldPath string) {
+	w.mu.Lock()
+	var state = w.worlds[filepath.Clean(worldPath)]
+	if state == nil {
+		w.mu.Unlock()
+		return
+	}
+	a, err := GetArchiveByID(state.archiveID)
+	if err != nil {
+		w.mu.Unlock()
+		return
+	}
+	// 存档已被删除 停止监听
+	if a == nil {
+		w.unwatch(filepath.Clean(worldPath))
+		w.mu.Unlock()
+		return
+	}
+
+	// 游戏正在写入时可能读到不完整的文件 等待下一次事件
+	dataVersion, versionName, ok := readGameVersion(a)
+	if !ok || dataVersion == state.dataVersion {
+		w.mu.Unlock()
+		return
+	}
+	var oldVersion, oldName = state.dataVersion, state.versionName
+	state.dataVersion, state.versionName = dataVersion, versionName
+	w.mu.Unlock()
+
+	if inUse, _ := IsArchiveInUse(a); !inUse || oldVersion == 0 || dataVersion < oldVersion {
+		return
+	}
+	onGameUpgraded(a.ID, oldVersion, oldName)
+}
+
+// onGameUpgraded 固定升级前的最后一个快照 并在备注中标明版本
+func onGameUpgraded(archiveID uint, oldVersion int, oldName string) {
+	a, err := GetArchiveByID(archiveID)
+	if err != nil || a == nil {
+		return
+	}
+	latest, err := GetLatestBackupRecordByArchiveID(archiveID)
+	if err != nil || latest == nil {
+		log.Printf("[版本监听] 存档[ %s ]已升级到新版本 但没有可以固定的快照\n", a.Name)
+		return
+	}
+	// 最新的快照已经是新版本的 说明之前已经处理过
+	if latest.DataVersion > oldVersion {
+		return
+	}
+
+	var label = fmt.Sprintf("最后的 %s 版本存档", VersionLabel(oldVersion, oldName))
+	if err := SetBackupRecordPinned(latest, true); err != nil {
+		log.Printf("[版本监听] 存档[ %s ]固定快照 %s 失败: %v\n", a.Name, latest.SnapShot, err)
+		return
+	}
+	if !strings.Contains(latest.Comment, label) {
+		latest.Comment = strings.TrimSpace(label + " " + latest.Comment)
+		if err := UpdateBackupRecord(latest); err != nil {
+			log.Printf("[版本监听] 存档[ %s ]修改快照备注失败: %v\n", a.Name, err)
+			return
+		}
+	}
+	log.Printf("[版本监听] 存档[ %s ]已升级 快照 %s 已固定为%s\n", a.Name, latest.SnapShot, label)
+}
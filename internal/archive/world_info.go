@@ -0,0 +1,73 @@
+package archive
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	"os"
+)
+
+// GetWorldInfo 获取存档的 level.dat 信息 服务器读取主世界 实例与文件夹返回 nil
+// level.dat 修改后重新解析并更新缓存 存档目录不可读时返回上一次的缓存 (可能为 nil)
+func GetWorldInfo(a *database.Archive) (*database.WorldInfo, error) {
+	var worldPath = WorldPath(a)
+	if worldPath == "" {
+		return nil, nil
+	}
+
+	var cached database.WorldInfo
+	result := DB.Where("archive_id = ?", a.ID).First(&cached)
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, result.Error
+	}
+	var found = result.Error == nil
+
+	stat, err := os.Stat(world.LevelDatPath(worldPath))
+	if err != nil {
+		if found {
+			return &cached, nil
+		}
+		return nil, nil
+	}
+
+	// 缓存仍然有效
+	if found && cached.LevelModTime.Equal(stat.ModTime()) {
+		return &cached, nil
+	}
+
+	level, err := world.ReadLevel(worldPath)
+	if err != nil {
+		if found {
+			return &cached, nil
+		}
+		return nil, err
+	}
+	icon, err := world.ReadIcon(worldPath)
+	if err != nil {
+		icon = nil
+	}
+
+	var info = database.WorldInfo{
+		ID:           cached.ID,
+		CreatedAt:    cached.CreatedAt,
+		ArchiveID:    a.ID,
+		LevelModTime: stat.ModTime(),
+		Bedrock:      level.Bedrock,
+		LevelName:    level.LevelName,
+		VersionName:  level.VersionName,
+		DataVersion:  level.DataVersion,
+		GameType:     level.GameType,
+		Hardcore:     level.Hardcore,
+		Difficulty:   level.Difficulty,
+		Seed:         level.Seed,
+		LastPlayed:   level.LastPlayed,
+		Days:         level.Days,
+		Icon:         icon,
+	}
+	if err := DB.Save(&info).Error; err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
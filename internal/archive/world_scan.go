@@ -0,0 +1,64 @@
+package archive
+
+import (
+	"fmt"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	"strings"
+)
+
+// 备份记录中最多保存的问题数
+const maxHealthIssues = 50
+
+// CanScanArchive 存档是否可以检查完整性 基岩版使用 LevelDB 无法检查
+func CanScanArchive(a *database.Archive) bool {
+	return NormalizeArchiveType(a.Type) != database.ArchiveTypeBedrock
+}
+
+// HealthOf 根据扫描结果得到健康状态
+func HealthOf(report *world.ScanReport) database.HealthStatus {
+	severity, ok := report.Worst()
+	switch {
+	case !ok:
+		return database.HealthHealthy
+	case severity == world.SeverityCorrupt:
+		return database.HealthCorrupt
+	}
+	return database.HealthWarnings
+}
+
+// HealthName 健康状态的名称
+func HealthName(health database.HealthStatus) string {
+	switch health {
+	case database.HealthHealthy:
+		return "完好"
+	case database.HealthWarnings:
+		return "有警告"
+	case database.HealthCorrupt:
+		return "已损坏"
+	}
+	return "未检查"
+}
+
+// ScanSummary 扫描结果的一行摘要
+func ScanSummary(report *world.ScanReport) string {
+	return fmt.Sprintf("检查了 %d 个文件、%d 个区块，发现 %d 处损坏、%d 个警告",
+		report.Files, report.Chunks, report.Count(world.SeverityCorrupt), report.Count(world.SeverityWarning))
+}
+
+// HealthReportText 保存到备份记录中的问题列表 问题过多时只保留最严重的一部分
+func HealthReportText(report *world.ScanReport) string {
+	var lines = []string{ScanSummary(report)}
+	for i, issue := range report.Issues {
+		if i == maxHealthIssues {
+			lines = append(lines, fmt.Sprintf("... 另有 %d 个问题", len(report.Issues)-maxHealthIssues))
+			break
+		}
+		var level = "警告"
+		if issue.Severity == world.SeverityCorrupt {
+			level = "损坏"
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s %s", level, issue.Path, issue.Message))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,112 @@
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"minecraft-archive-backup/pkg/nbt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 基岩版存档中的文件
+const (
+	BedrockLevelNameFile = "levelname.txt"
+	BedrockIconName      = "world_icon.jpeg"
+	bedrockDBDir         = "db"
+)
+
+// bedrockHeaderSize 基岩版 level.dat 开头的 存储版本(4字节) 与 数据长度(4字节)
+const bedrockHeaderSize = 8
+
+// IsBedrockWorld 判断文件夹是否为基岩版存档 (LevelDB 的 db 文件夹与 level.dat)
+func IsBedrockWorld(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, bedrockDBDir))
+	return err == nil && info.IsDir() && isFile(LevelDatPath(dir))
+}
+
+// BedrockLockPath LevelDB 的锁文件 游戏打开存档时会独占这个文件
+func BedrockLockPath(worldPath string) string {
+	return filepath.Join(worldPath, bedrockDBDir, "LOCK")
+}
+
+// IsBedrockWorldInUse 检查基岩版存档是否正在被游玩
+func IsBedrockWorldInUse(worldPath string) (bool, error) {
+	return IsWorldInUse(BedrockLockPath(worldPath))
+}
+
+// ReadBedrockLevel 读取基岩版的 level.dat (带 8 字节文件头的小端序 NBT)
+func ReadBedrockLevel(worldPath string) (*LevelInfo, error) {
+	data, err := os.ReadFile(LevelDatPath(worldPath))
+	if err != nil {
+		return nil, fmt.Errorf("读取 level.dat 失败: %w", err)
+	}
+	if len(data) < bedrockHeaderSize {
+		return nil, errors.New("基岩版 level.dat 文件头不完整")
+	}
+
+	var length = binary.LittleEndian.Uint32(data[4:8])
+	var payload = data[bedrockHeaderSize:]
+	if int(length) <= len(payload) {
+		payload = payload[:length]
+	}
+
+	_, root, err := nbt.NewLittleEndianDecoder(bytes.NewReader(payload)).Decode()
+	if err != nil {
+		return nil, fmt.Errorf("解析 level.dat 失败: %w", err)
+	}
+
+	var info = ParseBedrockLevel(root)
+
+	// levelname.txt 与游戏内显示的名称一致 优先使用
+	if name, err := os.ReadFile(filepath.Join(worldPath, BedrockLevelNameFile)); err == nil {
+		if trimmed := strings.TrimSpace(string(name)); trimmed != "" {
+			info.LevelName = trimmed
+		}
+	}
+
+	return info, nil
+}
+
+// ParseBedrockLevel 从已解码的基岩版 level.dat 中提取存档信息
+// 基岩版的 level.dat 没有 Data 标签 LastPlayed 的单位为秒
+func ParseBedrockLevel(root nbt.Compound) *LevelInfo {
+	var info = &LevelInfo{
+		Bedrock:     true,
+		LevelName:   root.String("LevelName"),
+		VersionName: bedrockVersion(root.List("lastOpenedWithVersion")),
+		DataVersion: int(root.Int("NetworkVersion")),
+		GameType:    int(root.Int("GameType")),
+		Hardcore:    root.Bool("IsHardcore"),
+		Difficulty:  int(root.Int("Difficulty")),
+		Seed:        root.Int("RandomSeed"),
+		Days:        root.Int("Time") / ticksPerDay,
+	}
+
+	if lastPlayed := root.Int("LastPlayed"); lastPlayed > 0 {
+		info.LastPlayed = time.Unix(lastPlayed, 0)
+	}
+
+	return info
+}
+
+// bedrockVersion 将 [1 21 50 7 0] 转换为 1.21.50
+func bedrockVersion(parts []any) string {
+	var version []string
+	for i, part := range parts {
+		if i >= 3 {
+			break
+		}
+		version = append(version, strconv.FormatInt(nbt.ToInt(part), 10))
+	}
+	return strings.Join(version, ".")
+}
+
+func isFile(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,153 @@
+package world
+
+import (
+	"math/bits"
+	"minecraft-archive-backup/pkg/nbt"
+	"strings"
+)
+
+// 区块的尺寸
+const (
+	ChunkWidth   = 16
+	ChunkColumns = ChunkWidth * ChunkWidth
+	sectionSize  = ChunkColumns * ChunkWidth
+)
+
+// 20w17a (1.16) 起 压缩数组中的值不再跨越两个 long
+const dataVersionNoSpan = 2529
+
+// Surface 区块中每一列最上方的方块 索引为 z*16+x
+type Surface struct {
+	Blocks  [ChunkColumns]string // 方块 ID 例如 minecraft:grass_block 没有方块时为空
+	Heights [ChunkColumns]int    // 方块的 Y 坐标
+}
+
+// ParseSurface 从解码后的区块中读取地表 支持 1.13 起的区块格式
+// 区块尚未生成完毕或缺少高度图时返回 nil
+func ParseSurface(root nbt.Compound) *Surface {
+	var dataVersion = root.Int("DataVersion")
+	var noSpan = dataVersion >= dataVersionNoSpan
+
+	// 1.18 之前区块数据位于 Level 中
+	var level = root
+	if l := root.Compound("Level"); l != nil {
+		level = l
+	}
+	if !chunkGenerated(level.String("Status")) {
+		return nil
+	}
+
+	var heightmaps = level.Compound("Heightmaps")
+	var heightmap = heightmaps.LongArray("MOTION_BLOCKING")
+	if heightmap == nil {
+		heightmap = heightmaps.LongArray("WORLD_SURFACE")
+	}
+	if heightmap == nil {
+		return nil
+	}
+	var heightBits = packedBits(len(heightmap), ChunkColumns, noSpan)
+	if heightBits == 0 {
+		return nil
+	}
+
+	// 1.18 起世界最低处为 yPos 个区段
+	var minY int
+	if level.Has("yPos") {
+		minY = int(level.Int("yPos")) * ChunkWidth
+	}
+
+	var sections = make(map[int]nbt.Compound)
+	for _, section := range append(level.CompoundList("sections"), level.CompoundList("Sections")...) {
+		sections[int(section.Int("Y"))] = section
+	}
+
+	var surface = &Surface{}
+	for i := 0; i < ChunkColumns; i++ {
+		var height = unpack(heightmap, heightBits, i, noSpan)
+		if height == 0 {
+			continue
+		}
+		var y = minY + height - 1
+		surface.Heights[i] = y
+		surface.Blocks[i] = blockAt(sections[y>>4], (y&15)*ChunkColumns+i, noSpan)
+	}
+	return surface
+}
+
+// InhabitedTime 玩家在区块附近停留的累计刻数 (20 刻为 1 秒)
+func InhabitedTime(root nbt.Compound) int64 {
+	if level := root.Compound("Level"); level != nil {
+		return level.Int("InhabitedTime")
+	}
+	return root.Int("InhabitedTime")
+}
+
+// chunkGenerated 区块是否已经生成完毕 旧版本的状态为 postprocessed / fullchunk
+func chunkGenerated(status string) bool {
+	return status == "" || strings.HasSuffix(status, "full") || status == "postprocessed" || status == "fullchunk"
+}
+
+// blockAt 区段中指定位置的方块 ID 索引为 (y*16+z)*16+x
+func blockAt(section nbt.Compound, index int, noSpan bool) string {
+	if section == nil {
+		return ""
+	}
+
+	// 1.18 起方块状态位于 block_states 中
+	var palette, data = section.CompoundList("Palette"), section.LongArray("BlockStates")
+	if states := section.Compound("block_states"); states != nil {
+		palette, data = states.CompoundList("palette"), states.LongArray("data")
+	}
+	if len(palette) == 0 {
+		return ""
+	}
+	if len(palette) == 1 || data == nil {
+		return palette[0].String("Name")
+	}
+
+	var paletteBits = max(4, bits.Len(uint(len(palette)-1)))
+	var paletteIndex = unpack(data, paletteBits, index, noSpan)
+	if paletteIndex >= len(palette) {
+		return ""
+	}
+	return palette[paletteIndex].String("Name")
+}
+
+// packedBits 根据数组长度推算每个值占用的位数 无法推算时返回 0
+func packedBits(longs, count int, noSpan bool) int {
+	if !noSpan {
+		return longs * 64 / count
+	}
+	for b := 1; b <= 32; b++ {
+		var perLong = 64 / b
+		if (count+perLong-1)/perLong == longs {
+			return b
+		}
+	}
+	return 0
+}
+
+// unpack 读取压缩数组中的第 index 个值
+func unpack(data []int64, bitCount, index int, noSpan bool) int {
+	var mask = uint64(1)<<bitCount - 1
+
+	if noSpan {
+		var perLong = 64 / bitCount
+		var i = index / perLong
+		if i >= len(data) {
+			return 0
+		}
+		return int(uint64(data[i]) >> ((index % perLong) * bitCount) & mask)
+	}
+
+	var bitIndex = index * bitCount
+	var i, offset = bitIndex / 64, bitIndex % 64
+	if i >= len(data) {
+		return 0
+	}
+	var value = uint64(data[i]) >> offset
+	if offset+bitCount > 64 && i+1 < len(data) {
+		value |= uint64(data[i+1]) << (64 - offset)
+	}
+	return int(value & mask)
+}
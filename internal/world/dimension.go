@@ -0,0 +1,59 @@
+package world
+
+import (
+	"strings"
+)
+
+// 维度中存放 .mca 文件的文件夹
+const (
+	RegionDir   = "region"   // 区块的方块数据
+	EntitiesDir = "entities" // 1.17 起单独存放的实体数据
+	PoiDir      = "poi"      // 村民工作站点等兴趣点
+)
+
+// DimensionName 根据 region 文件夹的上级路径 (相对存档根目录 以 / 分隔) 得到维度名称
+// 例如 "" -> 主世界 "DIM-1" -> 下界 "dimensions/mod/mining" -> mod:mining
+// 服务器的各个世界位于不同的文件夹中 名称前会带上世界文件夹 例如 world_nether / 下界
+func DimensionName(dir string) string {
+	var parts []string
+	if dir != "" {
+		parts = strings.Split(dir, "/")
+	}
+
+	var name = "主世界"
+	for i, part := range parts {
+		if part == "dimensions" && i+2 < len(parts) {
+			name = parts[i+1] + ":" + strings.Join(parts[i+2:], "/")
+			parts = parts[:i]
+			break
+		}
+	}
+	if name == "主世界" && len(parts) > 0 {
+		switch parts[len(parts)-1] {
+		case "DIM-1":
+			name = "下界"
+			parts = parts[:len(parts)-1]
+		case "DIM1":
+			name = "末地"
+			parts = parts[:len(parts)-1]
+		}
+	}
+
+	if len(parts) > 0 {
+		return strings.Join(parts, "/") + " / " + name
+	}
+	return name
+}
+
+// DimensionIDName 维度 ID 的名称 例如 minecraft:the_nether -> 下界 其他维度保持原样
+func DimensionIDName(id string) string {
+	switch id {
+	case "minecraft:overworld":
+		return "主世界"
+	case "minecraft:the_nether":
+		return "下界"
+	case "minecraft:the_end":
+		return "末地"
+	}
+	return id
+}
@@ -0,0 +1,240 @@
+package world
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 扫描启动器目录时的最大深度
+// 例如 PrismLauncher/instances/<实例>/.minecraft/saves/<存档> 或 .minecraft/versions/<版本>/saves/<存档>
+const maxDiscoverDepth = 6
+
+// skipDirs 扫描时不需要进入的目录 其中不会有存档
+var skipDirs = map[string]bool{
+	"assets":        true,
+	"libraries":     true,
+	"mods":          true,
+	"resourcepacks": true,
+	"shaderpacks":   true,
+	"screenshots":   true,
+	"logs":          true,
+	"crash-reports": true,
+	"natives":       true,
+	"config":        true,
+	"cache":         true,
+	"jre":           true,
+	"java":          true,
+	"runtime":       true,
+}
+
+// Root 扫描的根目录
+type Root struct {
+	Source string // 来源 例如 官方启动器
+	Path   string
+}
+
+// Candidate 扫描到的存档
+type Candidate struct {
+	Path       string
+	Name       string    // level.dat 中的世界名称 读取失败时为文件夹名称
+	Source     string    // 来源 包含启动器与实例名称
+	Bedrock    bool      // 基岩版存档
+	Size       int64     // 存档大小(字节)
+	LastPlayed time.Time // 最后游玩时间
+}
+
+// DefaultRoots 常见启动器的默认目录 只返回存在的目录
+// MultiMC、HMCL、PCL2 通常为便携版 需要用户手动添加其所在的文件夹
+func DefaultRoots() []Root {
+	var roots []Root
+
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		roots = append(roots,
+			Root{Source: "官方启动器", Path: filepath.Join(appData, ".minecraft")},
+			Root{Source: "PrismLauncher", Path: filepath.Join(appData, "PrismLauncher", "instances")},
+			Root{Source: "MultiMC", Path: filepath.Join(appData, "MultiMC", "instances")},
+			Root{Source: "HMCL", Path: filepath.Join(appData, ".hmcl")},
+		)
+
+		// 基岩版 GDK 版本按用户存放存档
+		if worldDirs, err := filepath.Glob(filepath.Join(appData, "Minecraft Bedrock", "Users", "*", "games", "com.mojang", "minecraftWorlds")); err == nil {
+			for _, dir := range worldDirs {
+				roots = append(roots, Root{Source: "基岩版", Path: dir})
+			}
+		}
+	}
+	if localAppData := os.Getenv("LOCALAPPDATA"); localAppData != "" {
+		roots = append(roots, Root{Source: "基岩版", Path: filepath.Join(localAppData,
+			"Packages", "Microsoft.MinecraftUWP_8wekyb3d8bbwe", "LocalState", "games", "com.mojang", "minecraftWorlds")})
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		roots = append(roots, Root{Source: "官方启动器", Path: filepath.Join(home, ".minecraft")})
+	}
+
+	var result []Root
+	var seen = make(map[string]bool)
+	for _, root := range roots {
+		var key = strings.ToLower(filepath.Clean(root.Path))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if info, err := os.Stat(root.Path); err == nil && info.IsDir() {
+			result = append(result, root)
+		}
+	}
+	return result
+}
+
+// Discover 扫描所有根目录下的存档 (包含 level.dat 的文件夹)
+// 同一个存档只返回一次 结果按最后游玩时间倒序排列
+func Discover(roots []Root) []*Candidate {
+	var candidates []*Candidate
+	var seen = make(map[string]bool)
+
+	for _, root := range roots {
+		for _, dir := range findWorlds(root.Path) {
+			var key = strings.ToLower(filepath.Clean(dir))
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			candidates = append(candidates, newCandidate(root, dir))
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].LastPlayed.After(candidates[j].LastPlayed)
+	})
+	return candidates
+}
+
+// findWorlds 查找根目录下所有的存档 找到存档后不再进入其子目录
+func findWorlds(root string) []string {
+	var worlds []string
+
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		if isWorld(dir) {
+			worlds = append(worlds, dir)
+			return
+		}
+		if depth >= maxDiscoverDepth {
+			return
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || skipDirs[strings.ToLower(entry.Name())] {
+				continue
+			}
+			walk(filepath.Join(dir, entry.Name()), depth+1)
+		}
+	}
+	walk(root, 0)
+
+	return worlds
+}
+
+// isWorld 判断文件夹是否为存档 Java 版与基岩版都有 level.dat
+func isWorld(dir string) bool {
+	return isFile(LevelDatPath(dir))
+}
+
+func newCandidate(root Root, dir string) *Candidate {
+	var candidate = &Candidate{
+		Path:    dir,
+		Name:    filepath.Base(dir),
+		Source:  describeSource(root, dir),
+		Bedrock: IsBedrockWorld(dir),
+		Size:    DirSize(dir),
+	}
+
+	if level, err := ReadLevel(dir); err == nil {
+		if level.LevelName != "" {
+			candidate.Name = level.LevelName
+		}
+		candidate.LastPlayed = level.LastPlayed
+	}
+	// 旧版本的 level.dat 中没有 LastPlayed 使用文件的修改时间
+	if candidate.LastPlayed.IsZero() {
+		if info, err := os.Stat(LevelDatPath(dir)); err == nil {
+			candidate.LastPlayed = info.ModTime()
+		}
+	}
+
+	return candidate
+}
+
+// describeSource 根据存档所处的目录结构描述来源
+// 例如 PrismLauncher / 实例名 或 HMCL / 版本隔离 1.20.1
+func describeSource(root Root, dir string) string {
+	var source = root.Source
+	if source == "" {
+		source = detectLauncher(dir)
+	}
+
+	rel, err := filepath.Rel(root.Path, dir)
+	if err != nil {
+		return source
+	}
+	var parts = strings.Split(filepath.ToSlash(rel), "/")
+
+	for i := 0; i+1 < len(parts); i++ {
+		switch strings.ToLower(parts[i]) {
+		case "instances":
+			return source + " / " + parts[i+1]
+		case "versions":
+			return source + " / 版本隔离 " + parts[i+1]
+		}
+	}
+	// 根目录本身就是实例目录
+	if root.Source == "PrismLauncher" || root.Source == "MultiMC" {
+		if len(parts) > 1 {
+			return source + " / " + parts[0]
+		}
+	}
+	return source
+}
+
+// detectLauncher 用户添加的目录 根据路径中的名称猜测启动器
+func detectLauncher(dir string) string {
+	var lower = strings.ToLower(filepath.ToSlash(dir))
+	switch {
+	case strings.Contains(lower, "prismlauncher"):
+		return "PrismLauncher"
+	case strings.Contains(lower, "multimc"):
+		return "MultiMC"
+	case strings.Contains(lower, "hmcl"):
+		return "HMCL"
+	case strings.Contains(lower, "pcl"):
+		return "PCL2"
+	}
+	return "自定义目录"
+}
+
+// DirSize 统计文件夹的大小
+func DirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
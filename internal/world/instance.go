@@ -0,0 +1,6 @@
+package world
+
+// CountMods 统计实例 mods 文件夹中启用的模组数量
+func CountMods(instancePath string) int {
+	return len(ListModJars(instancePath))
+}
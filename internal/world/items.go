@@ -0,0 +1,105 @@
+package world
+
+import (
+	"minecraft-archive-backup/pkg/nbt"
+	"strings"
+)
+
+// ContainerItems 方块实体 (箱子、木桶、潜影盒等) 中找到的物品
+type ContainerItems struct {
+	ID      string // 方块实体 ID 例如 minecraft:chest
+	X, Y, Z int
+	Count   int
+}
+
+// NormalizeItemID 补全物品 ID 的命名空间 例如 netherite_ingot -> minecraft:netherite_ingot
+func NormalizeItemID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id != "" && !strings.Contains(id, ":") {
+		id = "minecraft:" + id
+	}
+	return id
+}
+
+// FindChunkItems 在区块的方块实体中查找物品 包括潜影盒与收纳袋中的物品
+func FindChunkItems(root nbt.Compound, itemID string) []ContainerItems {
+	// 1.18 之前区块数据位于 Level 中
+	var level = root
+	if l := root.Compound("Level"); l != nil {
+		level = l
+	}
+
+	var found []ContainerItems
+	for _, entity := range append(level.CompoundList("block_entities"), level.CompoundList("TileEntities")...) {
+		var items = entity.CompoundList("Items")
+		if item := entity.Compound("item"); item != nil {
+			// 展示架、雕纹书架等只存放单个物品的方块实体
+			items = append(items, item)
+		}
+		if count := CountItem(items, itemID); count > 0 {
+			found = append(found, ContainerItems{
+				ID:    entity.String("id"),
+				X:     int(entity.Int("x")),
+				Y:     int(entity.Int("y")),
+				Z:     int(entity.Int("z")),
+				Count: count,
+			})
+		}
+	}
+	return found
+}
+
+// PlayerItems 玩家背包 (含盔甲与副手) 与末影箱中物品的数量
+func PlayerItems(root nbt.Compound, itemID string) (inventory, ender int) {
+	inventory = CountItem(root.CompoundList("Inventory"), itemID)
+	if equipment := root.Compound("equipment"); equipment != nil {
+		for key := range equipment {
+			inventory += CountItem([]nbt.Compound{equipment.Compound(key)}, itemID)
+		}
+	}
+	return inventory, CountItem(root.CompoundList("EnderItems"), itemID)
+}
+
+// CountItem 统计物品列表中指定物品的数量 会递归统计潜影盒与收纳袋的内容
+func CountItem(items []nbt.Compound, itemID string) int {
+	var total int
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if item.String("id") == itemID {
+			total += itemCount(item)
+		}
+		total += CountItem(nestedItems(item), itemID)
+	}
+	return total
+}
+
+// itemCount 物品的数量 1.20.5 起为 count 之前为 Count
+func itemCount(item nbt.Compound) int {
+	if item.Has("count") {
+		return int(item.Int("count"))
+	}
+	if item.Has("Count") {
+		return int(item.Int("Count"))
+	}
+	return 1
+}
+
+// nestedItems 物品内部存放的物品
+// 1.20.5 起位于 components 的 minecraft:container 与 minecraft:bundle_contents 中
+// 之前位于 tag.BlockEntityTag.Items 与 tag.Items 中
+func nestedItems(item nbt.Compound) []nbt.Compound {
+	var nested []nbt.Compound
+	if components := item.Compound("components"); components != nil {
+		for _, slot := range components.CompoundList("minecraft:container") {
+			nested = append(nested, slot.Compound("item"))
+		}
+		nested = append(nested, components.CompoundList("minecraft:bundle_contents")...)
+	}
+	if tag := item.Compound("tag"); tag != nil {
+		nested = append(nested, tag.Path("BlockEntityTag").CompoundList("Items")...)
+		nested = append(nested, tag.CompoundList("Items")...)
+	}
+	return nested
+}
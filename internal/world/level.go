@@ -0,0 +1,141 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"minecraft-archive-backup/pkg/nbt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Java 版存档中的文件
+const (
+	LevelDatName = "level.dat"
+	IconName     = "icon.png"
+)
+
+// 一个游戏日的刻数
+const ticksPerDay = 24000
+
+// LevelInfo level.dat 中的存档信息
+type LevelInfo struct {
+	Bedrock     bool // 基岩版存档
+	LevelName   string
+	VersionName string    // 游戏版本 例如 1.21.4
+	DataVersion int       // 数据版本号 用于判断存档格式 基岩版为 NetworkVersion
+	GameType    int       // 0 生存 1 创造 2 冒险 3 旁观
+	Hardcore    bool      // 极限模式
+	Difficulty  int       // 0 和平 1 简单 2 普通 3 困难
+	Seed        int64     // 世界种子
+	LastPlayed  time.Time // 最后游玩的时间
+	Days        int64     // 游戏内经过的天数
+	SpawnX      int       // 出生点的方块坐标
+	SpawnZ      int
+}
+
+// LevelDatPath 存档的 level.dat 路径
+func LevelDatPath(worldPath string) string {
+	return filepath.Join(worldPath, LevelDatName)
+}
+
+// IconPath 存档的 icon.png 路径
+func IconPath(worldPath string) string {
+	return filepath.Join(worldPath, IconName)
+}
+
+// ReadLevel 读取存档目录下的 level.dat 自动区分 Java 版与基岩版
+func ReadLevel(worldPath string) (*LevelInfo, error) {
+	if IsBedrockWorld(worldPath) {
+		return ReadBedrockLevel(worldPath)
+	}
+
+	root, err := nbt.ReadFile(LevelDatPath(worldPath))
+	if err != nil {
+		return nil, fmt.Errorf("读取 level.dat 失败: %w", err)
+	}
+	return ParseLevel(root)
+}
+
+// ParseLevel 从已解码的 level.dat 中提取存档信息
+func ParseLevel(root nbt.Compound) (*LevelInfo, error) {
+	var data = root.Compound("Data")
+	if data == nil {
+		return nil, errors.New("level.dat 中缺少 Data 标签")
+	}
+
+	var info = &LevelInfo{
+		LevelName:   data.String("LevelName"),
+		VersionName: data.Path("Version").String("Name"),
+		DataVersion: int(data.Int("DataVersion")),
+		GameType:    int(data.Int("GameType")),
+		Hardcore:    data.Bool("hardcore"),
+		Difficulty:  int(data.Int("Difficulty")),
+		Days:        data.Int("DayTime") / ticksPerDay,
+	}
+
+	// 1.16 起种子存放在 WorldGenSettings 中
+	if settings := data.Compound("WorldGenSettings"); settings != nil && settings.Has("seed") {
+		info.Seed = settings.Int("seed")
+	} else {
+		info.Seed = data.Int("RandomSeed")
+	}
+
+	if lastPlayed := data.Int("LastPlayed"); lastPlayed > 0 {
+		info.LastPlayed = time.UnixMilli(lastPlayed)
+	}
+
+	// 1.21.9 起出生点存放在 spawn 的 pos 中
+	if pos, ok := data.Path("spawn")["pos"].([]int32); ok && len(pos) == 3 {
+		info.SpawnX, info.SpawnZ = int(pos[0]), int(pos[2])
+	} else {
+		info.SpawnX, info.SpawnZ = int(data.Int("SpawnX")), int(data.Int("SpawnZ"))
+	}
+
+	return info, nil
+}
+
+// ReadIcon 读取存档图标 (Java 版为 icon.png 基岩版为 world_icon.jpeg) 没有图标时返回 nil
+func ReadIcon(worldPath string) ([]byte, error) {
+	for _, name := range []string{IconName, BedrockIconName} {
+		data, err := os.ReadFile(filepath.Join(worldPath, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		return data, err
+	}
+	return nil, nil
+}
+
+// GameTypeName 游戏模式名称
+func GameTypeName(gameType int, hardcore bool) string {
+	if hardcore {
+		return "极限"
+	}
+	switch gameType {
+	case 0:
+		return "生存"
+	case 1:
+		return "创造"
+	case 2:
+		return "冒险"
+	case 3:
+		return "旁观"
+	}
+	return "未知模式"
+}
+
+// DifficultyName 难度名称
+func DifficultyName(difficulty int) string {
+	switch difficulty {
+	case 0:
+		return "和平"
+	case 1:
+		return "简单"
+	case 2:
+		return "普通"
+	case 3:
+		return "困难"
+	}
+	return "未知难度"
+}
@@ -0,0 +1,206 @@
+package world
+
+import (
+	"hash/fnv"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// 常见方块在地图上的颜色 参考游戏内地图的配色
+var blockColors = map[string]color.RGBA{
+	"grass_block":       {127, 178, 56, 255},
+	"short_grass":       {127, 178, 56, 255},
+	"tall_grass":        {127, 178, 56, 255},
+	"dirt":              {151, 109, 77, 255},
+	"coarse_dirt":       {151, 109, 77, 255},
+	"rooted_dirt":       {151, 109, 77, 255},
+	"dirt_path":         {148, 121, 65, 255},
+	"farmland":          {143, 103, 66, 255},
+	"podzol":            {129, 86, 49, 255},
+	"mycelium":          {127, 63, 178, 255},
+	"mud":               {87, 92, 92, 255},
+	"sand":              {247, 233, 163, 255},
+	"sandstone":         {247, 233, 163, 255},
+	"red_sand":          {216, 127, 51, 255},
+	"gravel":            {112, 112, 112, 255},
+	"clay":              {164, 168, 184, 255},
+	"stone":             {112, 112, 112, 255},
+	"cobblestone":       {112, 112, 112, 255},
+	"andesite":          {112, 112, 112, 255},
+	"diorite":           {255, 252, 245, 255},
+	"granite":           {151, 109, 77, 255},
+	"deepslate":         {100, 100, 100, 255},
+	"tuff":              {57, 41, 35, 255},
+	"calcite":           {209, 177, 161, 255},
+	"water":             {64, 64, 255, 255},
+	"bubble_column":     {64, 64, 255, 255},
+	"kelp":              {64, 64, 255, 255},
+	"kelp_plant":        {64, 64, 255, 255},
+	"seagrass":          {64, 64, 255, 255},
+	"tall_seagrass":     {64, 64, 255, 255},
+	"lava":              {255, 0, 0, 255},
+	"ice":               {160, 160, 255, 255},
+	"packed_ice":        {160, 160, 255, 255},
+	"blue_ice":          {160, 160, 255, 255},
+	"snow":              {255, 255, 255, 255},
+	"snow_block":        {255, 255, 255, 255},
+	"powder_snow":       {255, 255, 255, 255},
+	"netherrack":        {112, 2, 0, 255},
+	"soul_sand":         {102, 76, 51, 255},
+	"soul_soil":         {102, 76, 51, 255},
+	"basalt":            {25, 25, 25, 255},
+	"blackstone":        {25, 25, 25, 255},
+	"crimson_nylium":    {189, 48, 49, 255},
+	"warped_nylium":     {22, 126, 134, 255},
+	"end_stone":         {247, 233, 163, 255},
+	"obsidian":          {25, 25, 25, 255},
+	"bedrock":           {112, 112, 112, 255},
+	"cactus":            {0, 124, 0, 255},
+	"sugar_cane":        {0, 124, 0, 255},
+	"bamboo":            {0, 124, 0, 255},
+	"pumpkin":           {216, 127, 51, 255},
+	"melon":             {127, 204, 25, 255},
+	"hay_block":         {229, 229, 51, 255},
+	"glass":             {200, 220, 230, 255},
+	"terracotta":        {216, 127, 51, 255},
+	"bricks":            {153, 51, 51, 255},
+	"moss_block":        {102, 127, 51, 255},
+	"moss_carpet":       {102, 127, 51, 255},
+	"lily_pad":          {0, 124, 0, 255},
+	"mangrove_roots":    {129, 86, 49, 255},
+	"mud_bricks":        {135, 107, 98, 255},
+	"prismarine":        {76, 127, 153, 255},
+	"sculk":             {13, 18, 23, 255},
+	"torch":             {255, 200, 80, 255},
+	"stone_bricks":      {112, 112, 112, 255},
+	"smooth_stone":      {112, 112, 112, 255},
+	"quartz_block":      {255, 252, 245, 255},
+	"iron_block":        {167, 167, 167, 255},
+	"gold_block":        {250, 238, 77, 255},
+	"diamond_block":     {92, 219, 213, 255},
+	"emerald_block":     {0, 217, 58, 255},
+	"copper_block":      {216, 127, 51, 255},
+	"amethyst_block":    {127, 63, 178, 255},
+	"dripstone_block":   {76, 50, 35, 255},
+	"pointed_dripstone": {76, 50, 35, 255},
+}
+
+// 按名称片段匹配的颜色 用于木头、树叶、羊毛等有多种变体的方块
+var blockColorSuffixes = []struct {
+	suffix string
+	color  color.RGBA
+}{
+	{"_leaves", color.RGBA{0, 124, 0, 255}},
+	{"_log", color.RGBA{102, 76, 51, 255}},
+	{"_wood", color.RGBA{102, 76, 51, 255}},
+	{"_planks", color.RGBA{143, 119, 72, 255}},
+	{"_stairs", color.RGBA{143, 119, 72, 255}},
+	{"_slab", color.RGBA{143, 119, 72, 255}},
+	{"_fence", color.RGBA{143, 119, 72, 255}},
+	{"_door", color.RGBA{143, 119, 72, 255}},
+	{"_ore", color.RGBA{112, 112, 112, 255}},
+	{"_wool", color.RGBA{230, 230, 230, 255}},
+	{"_carpet", color.RGBA{230, 230, 230, 255}},
+	{"_concrete", color.RGBA{200, 200, 200, 255}},
+	{"_terracotta", color.RGBA{160, 90, 60, 255}},
+	{"_glass", color.RGBA{200, 220, 230, 255}},
+	{"_glass_pane", color.RGBA{200, 220, 230, 255}},
+	{"_flower", color.RGBA{0, 124, 0, 255}},
+	{"_tulip", color.RGBA{0, 124, 0, 255}},
+	{"_mushroom", color.RGBA{151, 109, 77, 255}},
+	{"_coral", color.RGBA{64, 64, 255, 255}},
+	{"_coral_fan", color.RGBA{64, 64, 255, 255}},
+	{"_bricks", color.RGBA{153, 51, 51, 255}},
+}
+
+// BlockColor 方块在地图上的颜色 未收录的方块根据名称生成一个固定的灰色调
+func BlockColor(id string) color.RGBA {
+	var name = strings.TrimPrefix(id, "minecraft:")
+	if c, ok := blockColors[name]; ok {
+		return c
+	}
+	for _, rule := range blockColorSuffixes {
+		if strings.HasSuffix(name, rule.suffix) {
+			return rule.color
+		}
+	}
+
+	var h = fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	var v = uint8(110 + h.Sum32()%60)
+	return color.RGBA{v, v, v, 255}
+}
+
+// MapImage 俯视地图 每个方块对应一个像素
+type MapImage struct {
+	MinX, MinZ int // 图片左上角的方块坐标
+	image      *image.RGBA
+	heights    []int
+	drawn      []bool
+}
+
+// NewMapImage 创建覆盖 [minX, minX+width) x [minZ, minZ+height) 方块范围的地图
+func NewMapImage(minX, minZ, width, height int) *MapImage {
+	return &MapImage{
+		MinX:    minX,
+		MinZ:    minZ,
+		image:   image.NewRGBA(image.Rect(0, 0, width, height)),
+		heights: make([]int, width*height),
+		drawn:   make([]bool, width*height),
+	}
+}
+
+// DrawChunk 绘制一个区块的地表 超出地图范围的部分会被忽略
+func (m *MapImage) DrawChunk(chunkX, chunkZ int, surface *Surface) {
+	var bounds = m.image.Bounds()
+	for i, block := range surface.Blocks {
+		if block == "" || strings.HasSuffix(block, "air") {
+			continue
+		}
+		var px = chunkX*ChunkWidth + i%ChunkWidth - m.MinX
+		var pz = chunkZ*ChunkWidth + i/ChunkWidth - m.MinZ
+		if !image.Pt(px, pz).In(bounds) {
+			continue
+		}
+
+		var offset = pz*bounds.Dx() + px
+		m.image.SetRGBA(px, pz, BlockColor(block))
+		m.heights[offset] = surface.Heights[i]
+		m.drawn[offset] = true
+	}
+}
+
+// Image 按照与北侧方块的高度差添加明暗 得到最终的图片
+func (m *MapImage) Image() *image.RGBA {
+	var bounds = m.image.Bounds()
+	var result = image.NewRGBA(bounds)
+	for z := 0; z < bounds.Dy(); z++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			var offset = z*bounds.Dx() + x
+			if !m.drawn[offset] {
+				continue
+			}
+
+			var c = m.image.RGBAAt(x, z)
+			if z > 0 && m.drawn[offset-bounds.Dx()] {
+				switch north := m.heights[offset-bounds.Dx()]; {
+				case m.heights[offset] > north:
+					c = shade(c, 1.15)
+				case m.heights[offset] < north:
+					c = shade(c, 0.8)
+				}
+			}
+			result.SetRGBA(x, z, c)
+		}
+	}
+	return result
+}
+
+// shade 调整颜色的亮度
+func shade(c color.RGBA, factor float64) color.RGBA {
+	var scale = func(v uint8) uint8 {
+		return uint8(min(255, float64(v)*factor))
+	}
+	return color.RGBA{scale(c.R), scale(c.G), scale(c.B), c.A}
+}
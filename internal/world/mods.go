@@ -0,0 +1,278 @@
+package world
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pelletier/go-toml/v2"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	ModsDir      = "mods"
+	DatapacksDir = "datapacks"
+	LibrariesDir = "libraries"
+)
+
+// ModManifest 快照对应的模组与数据包清单
+type ModManifest struct {
+	Loader    string    `json:"loader,omitempty"` // 加载器与版本 例如 Fabric 0.16.9
+	Datapacks []string  `json:"datapacks,omitempty"`
+	Mods      []ModFile `json:"mods,omitempty"`
+}
+
+// Empty 没有任何内容 原版存档
+func (m *ModManifest) Empty() bool {
+	return m.Loader == "" && len(m.Datapacks) == 0 && len(m.Mods) == 0
+}
+
+// ModFile mods 文件夹中的一个 jar 一个 jar 中可能包含多个模组
+type ModFile struct {
+	File string    `json:"file"`
+	SHA1 string    `json:"sha1"`
+	Mods []ModInfo `json:"mods,omitempty"`
+}
+
+// ModInfo 模组的 ID 与版本
+type ModInfo struct {
+	ID      string `json:"id"`
+	Version string `json:"version,omitempty"`
+}
+
+// loaders 可以识别的加载器 uid 为 MultiMC 与 PrismLauncher 中的组件名称
+var loaders = []struct {
+	name, group, artifact, uid string
+}{
+	{"Quilt", "org.quiltmc", "quilt-loader", "org.quiltmc.quilt-loader"},
+	{"Fabric", "net.fabricmc", "fabric-loader", "net.fabricmc.fabric-loader"},
+	{"NeoForge", "net.neoforged", "neoforge", "net.neoforged"},
+	{"Forge", "net.minecraftforge", "forge", "net.minecraftforge"},
+}
+
+// ListDatapacks 世界中的数据包 文件夹与压缩包的名称
+func ListDatapacks(worldPath string) []string {
+	entries, err := os.ReadDir(filepath.Join(worldPath, DatapacksDir))
+	if err != nil {
+		return nil
+	}
+
+	var packs []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.EqualFold(filepath.Ext(entry.Name()), ".zip") {
+			packs = append(packs, entry.Name())
+		}
+	}
+	return packs
+}
+
+// ListModJars 实例 mods 文件夹中启用的模组文件 与 CountMods 统计的范围相同
+func ListModJars(instancePath string) []string {
+	entries, err := os.ReadDir(filepath.Join(instancePath, ModsDir))
+	if err != nil {
+		return nil
+	}
+
+	var jars []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".jar") {
+			jars = append(jars, filepath.Join(instancePath, ModsDir, entry.Name()))
+		}
+	}
+	return jars
+}
+
+// ReadModJar 计算模组文件的 SHA-1 并读取其中的模组 ID
+// 支持 fabric.mod.json、quilt.mod.json 与 (neoforge.)mods.toml 无法识别的 jar 只记录文件名
+func ReadModJar(path string) (ModFile, error) {
+	var mod = ModFile{File: filepath.Base(path)}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return mod, err
+	}
+	defer file.Close()
+
+	var hash = sha1.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return mod, err
+	}
+	mod.SHA1 = hex.EncodeToString(hash.Sum(nil))
+
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return mod, nil
+	}
+	defer reader.Close()
+
+	var files = make(map[string]*zip.File)
+	for _, f := range reader.File {
+		files[f.Name] = f
+	}
+
+	switch {
+	case files["quilt.mod.json"] != nil:
+		var meta struct {
+			QuiltLoader ModInfo `json:"quilt_loader"`
+		}
+		if readZipJSON(files["quilt.mod.json"], &meta) == nil && meta.QuiltLoader.ID != "" {
+			mod.Mods = append(mod.Mods, meta.QuiltLoader)
+		}
+	case files["fabric.mod.json"] != nil:
+		var meta ModInfo
+		if readZipJSON(files["fabric.mod.json"], &meta) == nil && meta.ID != "" {
+			mod.Mods = append(mod.Mods, meta)
+		}
+	default:
+		for _, name := range []string{"META-INF/neoforge.mods.toml", "META-INF/mods.toml"} {
+			if files[name] != nil {
+				mod.Mods = readModsToml(files[name], files["META-INF/MANIFEST.MF"])
+				break
+			}
+		}
+	}
+	return mod, nil
+}
+
+// readZipJSON 解析压缩包中的 JSON 文件
+func readZipJSON(f *zip.File, v any) error {
+	data, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// readZipFile 读取压缩包中的文件
+func readZipFile(f *zip.File) ([]byte, error) {
+	reader, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// readModsToml 读取 Forge 与 NeoForge 的模组信息
+// 版本为 ${file.jarVersion} 时取 MANIFEST.MF 中的 Implementation-Version
+func readModsToml(f, manifest *zip.File) []ModInfo {
+	data, err := readZipFile(f)
+	if err != nil {
+		return nil
+	}
+	var meta struct {
+		Mods []struct {
+			ModID   string `toml:"modId"`
+			Version string `toml:"version"`
+		} `toml:"mods"`
+	}
+	if toml.Unmarshal(data, &meta) != nil {
+		return nil
+	}
+
+	var mods []ModInfo
+	for _, m := range meta.Mods {
+		var version = m.Version
+		if strings.Contains(version, "${file.jarVersion}") && manifest != nil {
+			version = manifestVersion(manifest)
+		}
+		mods = append(mods, ModInfo{ID: m.ModID, Version: version})
+	}
+	return mods
+}
+
+// manifestVersion MANIFEST.MF 中的 Implementation-Version
+func manifestVersion(f *zip.File) string {
+	reader, err := f.Open()
+	if err != nil {
+		return ""
+	}
+	defer reader.Close()
+
+	var scanner = bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "Implementation-Version:"); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// DetectLoader 读取启动器记录的加载器版本
+// 支持 MultiMC 与 PrismLauncher 的 mmc-pack.json 以及版本隔离时 versions/<版本>/<版本>.json
+func DetectLoader(instancePath string) string {
+	// MultiMC 与 PrismLauncher 的实例目录为 .minecraft 的上级目录
+	for _, dir := range []string{instancePath, filepath.Dir(instancePath)} {
+		var pack struct {
+			Components []struct {
+				UID     string `json:"uid"`
+				Version string `json:"version"`
+			} `json:"components"`
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "mmc-pack.json"))
+		if err != nil || json.Unmarshal(data, &pack) != nil {
+			continue
+		}
+		for _, loader := range loaders {
+			for _, component := range pack.Components {
+				if component.UID == loader.uid {
+					return loader.name + " " + component.Version
+				}
+			}
+		}
+		return ""
+	}
+
+	// 官方启动器格式的版本文件 加载器以依赖库的形式出现
+	var name = filepath.Base(instancePath)
+	data, err := os.ReadFile(filepath.Join(instancePath, name+".json"))
+	if err != nil {
+		return ""
+	}
+	var version struct {
+		Libraries []struct {
+			Name string `json:"name"`
+		} `json:"libraries"`
+	}
+	if json.Unmarshal(data, &version) != nil {
+		return ""
+	}
+	for _, loader := range loaders {
+		for _, library := range version.Libraries {
+			var parts = strings.Split(library.Name, ":")
+			if len(parts) >= 3 && parts[0] == loader.group && parts[1] == loader.artifact {
+				return loader.name + " " + parts[2]
+			}
+		}
+	}
+	return ""
+}
+
+// DetectServerLoader 根据服务器 libraries 文件夹中的加载器推断版本
+// 存在多个版本时取名称最大的一个 通常为升级后的版本
+func DetectServerLoader(serverPath string) string {
+	for _, loader := range loaders {
+		var dir = filepath.Join(serverPath, LibrariesDir, filepath.FromSlash(strings.ReplaceAll(loader.group, ".", "/")), loader.artifact)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		var versions []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				versions = append(versions, entry.Name())
+			}
+		}
+		if len(versions) > 0 {
+			sort.Strings(versions)
+			return loader.name + " " + versions[len(versions)-1]
+		}
+	}
+	return ""
+}
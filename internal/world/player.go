@@ -0,0 +1,140 @@
+package world
+
+import (
+	"encoding/json"
+	"errors"
+	"minecraft-archive-backup/pkg/nbt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 玩家数据所在的文件夹 (相对世界文件夹)
+const (
+	PlayerDataDir    = "playerdata"
+	StatsDir         = "stats"
+	AdvancementsDir  = "advancements"
+	UserCacheName    = "usercache.json"
+	playerDataSuffix = ".dat"
+)
+
+// UserCacheEntry usercache.json 中的一项
+type UserCacheEntry struct {
+	Name string `json:"name"`
+	UUID string `json:"uuid"`
+}
+
+// PlayerSummary playerdata 中玩家的概要
+type PlayerSummary struct {
+	X, Y, Z   float64
+	Dimension string
+	Health    float64
+	XPLevel   int
+	ItemCount int // 背包 (含盔甲与副手) 中物品的总数量
+	EnderItem int // 末影箱中物品的总数量
+}
+
+// ReadUserCache 读取玩家名称与 UUID 的对应关系 文件不存在时返回空列表
+func ReadUserCache(path string) ([]UserCacheEntry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []UserCacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// PlayerUUIDs 世界中所有有存档数据的玩家
+func PlayerUUIDs(worldPath string) []string {
+	entries, err := os.ReadDir(filepath.Join(worldPath, PlayerDataDir))
+	if err != nil {
+		return nil
+	}
+
+	var uuids []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), playerDataSuffix); ok && !entry.IsDir() {
+			uuids = append(uuids, name)
+		}
+	}
+	return uuids
+}
+
+// PlayerFiles 玩家在世界中的文件 (相对世界文件夹 以 / 分隔)
+func PlayerFiles(uuid string, stats, advancements bool) []string {
+	var files = []string{PlayerDataDir + "/" + uuid + playerDataSuffix}
+	if stats {
+		files = append(files, StatsDir+"/"+uuid+".json")
+	}
+	if advancements {
+		files = append(files, AdvancementsDir+"/"+uuid+".json")
+	}
+	return files
+}
+
+// ParsePlayer 从解码后的 playerdata 中提取概要
+func ParsePlayer(root nbt.Compound) *PlayerSummary {
+	var summary = &PlayerSummary{
+		Dimension: playerDimension(root),
+		Health:    root.Float("Health"),
+		XPLevel:   int(root.Int("XpLevel")),
+		ItemCount: countItems(root.CompoundList("Inventory")),
+		EnderItem: countItems(root.CompoundList("EnderItems")),
+	}
+
+	if pos := root.List("Pos"); len(pos) == 3 {
+		var values [3]float64
+		for i, v := range pos {
+			values[i], _ = v.(float64)
+		}
+		summary.X, summary.Y, summary.Z = values[0], values[1], values[2]
+	}
+
+	// 1.21.5 起盔甲与副手单独存放在 equipment 中
+	if equipment := root.Compound("equipment"); equipment != nil {
+		for key := range equipment {
+			summary.ItemCount += countItems([]nbt.Compound{equipment.Compound(key)})
+		}
+	}
+
+	return summary
+}
+
+// playerDimension 1.16 起维度为字符串 旧版本为数字
+func playerDimension(root nbt.Compound) string {
+	if dimension := root.String("Dimension"); dimension != "" {
+		return dimension
+	}
+	switch root.Int("Dimension") {
+	case -1:
+		return "minecraft:the_nether"
+	case 1:
+		return "minecraft:the_end"
+	}
+	return "minecraft:overworld"
+}
+
+// countItems 统计物品数量 1.20.5 起数量字段为 count 之前为 Count
+func countItems(items []nbt.Compound) int {
+	var total int
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if item.Has("count") {
+			total += int(item.Int("count"))
+		} else if item.Has("Count") {
+			total += int(item.Int("Count"))
+		} else if item.String("id") != "" {
+			total++
+		}
+	}
+	return total
+}
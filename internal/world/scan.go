@@ -0,0 +1,235 @@
+package world
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"minecraft-archive-backup/pkg/nbt"
+	"minecraft-archive-backup/pkg/region"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 同时检查的文件数
+const scanWorkers = 4
+
+// Severity 问题的严重程度
+type Severity int
+
+const (
+	SeverityWarning Severity = iota // 不影响游戏 例如文件大小未对齐
+	SeverityCorrupt                 // 数据无法读取 游戏中会丢失对应的内容
+)
+
+// Issue 扫描发现的问题
+type Issue struct {
+	Severity Severity
+	Path     string // 相对扫描根目录的路径
+	Message  string
+}
+
+// ScanReport 扫描的结果
+type ScanReport struct {
+	Files  int // 检查的文件数
+	Chunks int // 检查的区块数
+	Issues []Issue
+}
+
+// Worst 最严重的问题 没有问题时 ok 为 false
+func (r *ScanReport) Worst() (severity Severity, ok bool) {
+	for _, issue := range r.Issues {
+		if !ok || issue.Severity > severity {
+			severity, ok = issue.Severity, true
+		}
+	}
+	return severity, ok
+}
+
+// Count 指定严重程度的问题数
+func (r *ScanReport) Count(severity Severity) int {
+	var count int
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// regionFolders 存放区域文件的文件夹
+var regionFolders = []string{RegionDir, EntitiesDir, PoiDir}
+
+// ScanWorld 检查目录中所有的 level.dat、区域文件与玩家数据
+// 会解压每个区块 大型存档需要较长时间
+func ScanWorld(roots ...string) (*ScanReport, error) {
+	type task struct {
+		root, path string
+		check      func(path string) ([]Issue, int)
+	}
+
+	var tasks []task
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+
+			var folder = filepath.Base(filepath.Dir(p))
+			switch {
+			case d.Name() == LevelDatName:
+				tasks = append(tasks, task{root, p, checkLevelDat})
+			case slices.Contains(regionFolders, folder) && strings.HasSuffix(d.Name(), ".mca"):
+				tasks = append(tasks, task{root, p, checkRegion})
+			case folder == PlayerDataDir && strings.HasSuffix(d.Name(), playerDataSuffix):
+				tasks = append(tasks, task{root, p, checkPlayerData})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("读取存档文件失败: %w", err)
+		}
+	}
+
+	var (
+		report = &ScanReport{Files: len(tasks)}
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, scanWorkers)
+	)
+	for _, t := range tasks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			issues, chunks := t.check(t.path)
+			var rel, _ = filepath.Rel(t.root, t.path)
+			if len(roots) > 1 {
+				rel = filepath.Join(filepath.Base(t.root), rel)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Chunks += chunks
+			for _, issue := range issues {
+				issue.Path = filepath.ToSlash(rel)
+				report.Issues = append(report.Issues, issue)
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		if report.Issues[i].Severity != report.Issues[j].Severity {
+			return report.Issues[i].Severity > report.Issues[j].Severity
+		}
+		return report.Issues[i].Path < report.Issues[j].Path
+	})
+	return report, nil
+}
+
+// checkLevelDat 检查 level.dat 能否解析 (level.dat_old 不检查)
+func checkLevelDat(path string) ([]Issue, int) {
+	root, err := nbt.ReadFile(path)
+	if err == nil {
+		_, err = ParseLevel(root)
+	}
+	if err != nil {
+		return []Issue{{Severity: SeverityCorrupt, Message: fmt.Sprintf("level.dat 无法读取: %v", err)}}, 0
+	}
+	return nil, 0
+}
+
+// checkPlayerData 检查玩家数据是否为空或被截断
+func checkPlayerData(path string) ([]Issue, int) {
+	data, err := os.ReadFile(path)
+	switch {
+	case err != nil:
+		return []Issue{{Severity: SeverityCorrupt, Message: err.Error()}}, 0
+	case len(data) == 0:
+		return []Issue{{Severity: SeverityCorrupt, Message: "玩家数据为空文件"}}, 0
+	}
+	if _, err := nbt.DecodeBytes(data); err != nil {
+		return []Issue{{Severity: SeverityCorrupt, Message: fmt.Sprintf("玩家数据被截断或损坏: %v", err)}}, 0
+	}
+	return nil, 0
+}
+
+// checkRegion 检查区域文件的文件头 并解压每个区块
+func checkRegion(path string) ([]Issue, int) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []Issue{{Severity: SeverityCorrupt, Message: err.Error()}}, 0
+	}
+
+	var issues []Issue
+	var broken = make(map[int]bool)
+	for _, problem := range region.Check(data) {
+		var issue = Issue{Severity: SeverityWarning, Message: problem.Message}
+		if problem.Fatal {
+			issue.Severity = SeverityCorrupt
+			broken[problem.Index] = true
+		}
+		if problem.Index >= 0 {
+			issue.Message = chunkLabel(path, problem.Index) + problem.Message
+		}
+		issues = append(issues, issue)
+	}
+	if broken[-1] {
+		return issues, 0
+	}
+
+	// 文件头有问题的区块已经报告过 其余区块逐个解压
+	var header, _ = region.ParseHeader(data)
+	var chunks int
+	for i, location := range header.Locations {
+		if !location.Exists() || broken[i] {
+			continue
+		}
+		chunks++
+
+		var start = int(location.Offset) * region.SectorSize
+		var length = int(binary.BigEndian.Uint32(data[start:]))
+		var compression = data[start+4]
+
+		// 过大的区块存放在单独的 .mcc 文件中
+		if compression&0x80 != 0 {
+			regionX, regionZ, _ := region.ParseFileName(filepath.Base(path))
+			chunkX, chunkZ := region.ChunkPos(regionX, regionZ, i)
+			if _, err := os.Stat(filepath.Join(filepath.Dir(path), region.ExternalFileName(chunkX, chunkZ))); err != nil {
+				issues = append(issues, Issue{Severity: SeverityCorrupt, Message: chunkLabel(path, i) + "缺少单独存放的区块文件"})
+			}
+			continue
+		}
+
+		// 1 gzip 2 zlib 3 未压缩
+		switch compression {
+		case 1, 2, 3:
+			if _, err := nbt.DecodeBytes(data[start+5 : start+4+length]); err != nil {
+				issues = append(issues, Issue{Severity: SeverityCorrupt, Message: chunkLabel(path, i) + fmt.Sprintf("解压失败: %v", err)})
+			}
+		default:
+			// 1.20.5 起可选的 LZ4 等压缩方式无法验证
+			issues = append(issues, Issue{Severity: SeverityWarning, Message: chunkLabel(path, i) + fmt.Sprintf("无法验证压缩方式 %d", compression)})
+		}
+	}
+	return issues, chunks
+}
+
+// chunkLabel 区块的坐标 用于问题描述
+func chunkLabel(path string, index int) string {
+	regionX, regionZ, ok := region.ParseFileName(filepath.Base(path))
+	if !ok {
+		return fmt.Sprintf("区块 #%d: ", index)
+	}
+	chunkX, chunkZ := region.ChunkPos(regionX, regionZ, index)
+	return fmt.Sprintf("区块 (%d, %d): ", chunkX, chunkZ)
+}
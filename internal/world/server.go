@@ -0,0 +1,53 @@
+package world
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ServerPropertiesName 服务器配置文件
+const ServerPropertiesName = "server.properties"
+
+// ServerLevelName 服务器主世界的文件夹名称 读取 server.properties 中的 level-name 默认为 world
+func ServerLevelName(serverPath string) string {
+	file, err := os.Open(filepath.Join(serverPath, ServerPropertiesName))
+	if err != nil {
+		return "world"
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line = strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(key) == "level-name" {
+			if value = strings.TrimSpace(value); value != "" {
+				return value
+			}
+		}
+	}
+	return "world"
+}
+
+// ServerWorlds 服务器目录下的所有世界 (包含 level.dat 的子文件夹)
+// Bukkit 系服务端会把下界与末地放在 world_nether、world_the_end 中
+func ServerWorlds(serverPath string) []string {
+	entries, err := os.ReadDir(serverPath)
+	if err != nil {
+		return nil
+	}
+
+	var worlds []string
+	for _, entry := range entries {
+		var dir = filepath.Join(serverPath, entry.Name())
+		if entry.IsDir() && isWorld(dir) {
+			worlds = append(worlds, dir)
+		}
+	}
+	return worlds
+}
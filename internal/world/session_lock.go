@@ -1,4 +1,4 @@
-package history_page
+package world
 
 import (
 	"fmt"
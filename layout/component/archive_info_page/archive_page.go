@@ -9,10 +9,16 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/layout/component/world_info"
 	"minecraft-archive-backup/layout/manage"
 	"minecraft-archive-backup/model/dto/database"
+	"strconv"
+	"strings"
 )
 
+// bytesPerGB 配额输入框使用 GB 作为单位
+const bytesPerGB = 1 << 30
+
 // OperationMode 操作模式
 type OperationMode int
 
@@ -25,7 +31,7 @@ func NewWindow(info *database.Archive, mode OperationMode, refreshCallback func(
 	window := manage.GetWindow()
 
 	// 调整大小
-	window.Resize(fyne.NewSize(420, 445))
+	window.Resize(fyne.NewSize(420, 600))
 
 	// 设置标题
 	var title string
@@ -87,6 +93,91 @@ func newContent(window fyne.Window, info *database.Archive, mode OperationMode,
 	}
 	pathEntry.Text = info.Path
 
+	// 附加路径
+	extraPathEntry := widget.NewMultiLineEntry()
+	extraPathEntry.SetPlaceHolder("每行一个，例如服务器的 world_nether、world_the_end、plugins（可选）")
+	extraPathEntry.SetMinRowsVisible(3)
+	extraPathEntry.Text = strings.Join(info.BackupPaths()[1:], "\n")
+
+	// 存储配额
+	quotaEntry := widget.NewEntry()
+	quotaEntry.SetPlaceHolder("单位 GB，留空或 0 表示不限制")
+	quotaEntry.Validator = func(s string) error {
+		_, err := parseQuota(s)
+		return err
+	}
+	if info.Quota > 0 {
+		quotaEntry.Text = strconv.FormatFloat(float64(info.Quota)/bytesPerGB, 'f', -1, 64)
+	}
+
+	// 排除规则
+	excludeEntry := widget.NewMultiLineEntry()
+	excludeEntry.SetPlaceHolder("每行一条，以 / 开头表示相对存档根目录（可选）")
+	excludeEntry.SetMinRowsVisible(4)
+	excludeEntry.Text = info.Excludes
+
+	// 规范化区域文件
+	normalizeCheck := widget.NewCheck("备份前规范化区域文件", nil)
+	normalizeCheck.Checked = info.NormalizeRegions
+	normalizeTip := widget.NewLabel("在暂存的副本中按区块顺序重排 .mca 文件并清零未使用的扇区，减少重复数据，不会修改存档本身。仅对世界与服务器生效，需要额外的磁盘空间。")
+	normalizeTip.Wrapping = fyne.TextWrapWord
+	normalizeTip.SizeName = theme.SizeNameCaptionText
+
+	// 备份前检查存档完整性
+	scanCheck := widget.NewCheck("备份前检查存档完整性", nil)
+	scanCheck.Checked = info.ScanBeforeBackup
+	scanTip := widget.NewLabel("检查 level.dat、区域文件头与每个区块能否解压，以及玩家数据是否为空或被截断，结果显示在备份历史中。大型存档会明显增加备份时间。")
+	scanTip.Wrapping = fyne.TextWrapWord
+	scanTip.SizeName = theme.SizeNameCaptionText
+
+	// 存档类型 切换类型时 未修改过的默认排除规则会替换为新类型的默认规则
+	var typeNames = make([]string, 0, len(archive.ArchiveTypes))
+	for _, t := range archive.ArchiveTypes {
+		typeNames = append(typeNames, t.Name)
+	}
+	var selectedType = archive.NormalizeArchiveType(info.Type)
+	typeDescription := widget.NewLabel("")
+	typeDescription.Wrapping = fyne.TextWrapWord
+	typeDescription.SizeName = theme.SizeNameCaptionText
+	typeSelect := widget.NewSelect(typeNames, func(name string) {
+		for _, t := range archive.ArchiveTypes {
+			if t.Name != name {
+				continue
+			}
+			var oldDefaults = strings.Join(archive.DefaultExcludes(selectedType), "\n")
+			if mode == ModeCreate && (strings.TrimSpace(excludeEntry.Text) == "" || excludeEntry.Text == oldDefaults) {
+				excludeEntry.SetText(strings.Join(archive.DefaultExcludes(t.Type), "\n"))
+			}
+			selectedType = t.Type
+			typeDescription.SetText(t.Description)
+		}
+	})
+	if mode == ModeCreate && info.Excludes == "" {
+		excludeEntry.Text = strings.Join(archive.DefaultExcludes(selectedType), "\n")
+	}
+	typeSelect.SetSelected(archive.ArchiveTypeName(selectedType))
+
+	// 排除规则预设
+	var presetNames = make([]string, 0, len(archive.ExcludePresets))
+	for _, preset := range archive.ExcludePresets {
+		presetNames = append(presetNames, preset.Name)
+	}
+	var presetSelect *widget.Select
+	presetSelect = widget.NewSelect(presetNames, func(name string) {
+		if name == "" {
+			return
+		}
+		excludeEntry.SetText(appendPreset(excludeEntry.Text, name))
+		presetSelect.ClearSelected()
+	})
+	presetSelect.PlaceHolder = "添加预设"
+
+	// 预览排除规则
+	previewButton := widget.NewButtonWithIcon("预览", theme.SearchIcon(), func() {
+		var roots = append([]string{pathEntry.Text}, parseExtraPaths(extraPathEntry.Text)...)
+		showExcludePreview(window, roots, excludeEntry.Text)
+	})
+
 	// 取消按钮
 	cancelButton := widget.NewButtonWithIcon("取消", theme.CancelIcon(), func() {
 		manage.PutWindow(window)
@@ -117,13 +208,45 @@ func newContent(window fyne.Window, info *database.Archive, mode OperationMode,
 			return
 		}
 
+		var extraPaths = parseExtraPaths(extraPathEntry.Text)
+		for _, p := range extraPaths {
+			if !IsValidPathFormat(p) {
+				dialog.NewInformation("注意！", fmt.Sprintf("附加路径格式有误: %s", p), window).Show()
+				return
+			}
+		}
+
+		// 按类型检查路径
+		var check = &database.Archive{Type: selectedType, Path: pathEntry.Text}
+		for _, p := range extraPaths {
+			check.ExtraPaths = append(check.ExtraPaths, database.ArchivePath{Path: p})
+		}
+		if err := archive.ValidateArchive(check); err != nil {
+			dialog.NewInformation("注意！", err.Error(), window).Show()
+			return
+		}
+
+		quota, err := parseQuota(quotaEntry.Text)
+		if err != nil {
+			dialog.NewInformation("注意！", err.Error(), window).Show()
+			return
+		}
+
 		// 更新info对象
 		info.Name = nameEntry.Text
 		info.Comment = commentEntry.Text
+		info.Type = selectedType
 		info.Path = pathEntry.Text
+		info.ExtraPaths = make([]database.ArchivePath, 0, len(extraPaths))
+		for _, p := range extraPaths {
+			info.ExtraPaths = append(info.ExtraPaths, database.ArchivePath{Path: p})
+		}
+		info.Quota = quota
+		info.Excludes = strings.TrimSpace(excludeEntry.Text)
+		info.NormalizeRegions = normalizeCheck.Checked
+		info.ScanBeforeBackup = scanCheck.Checked
 
 		// 根据模式执行不同操作
-		var err error
 		if mode == ModeCreate {
 			err = createArchive(info)
 		} else {
@@ -160,11 +283,31 @@ func newContent(window fyne.Window, info *database.Archive, mode OperationMode,
 		layout.NewSpacer(),
 	)
 
+	// 编辑世界或服务器时 展示从 level.dat 读取的存档信息
+	worldInfoBox := container.NewVBox()
+	if mode == ModeEdit && archive.WorldPath(info) != "" {
+		if worldInfo, err := archive.GetWorldInfo(info); err != nil {
+			worldInfoBox.Add(widget.NewLabel(err.Error()))
+		} else {
+			worldInfoBox.Add(world_info.NewDetails(worldInfo))
+		}
+		worldInfoBox.Add(widget.NewSeparator())
+	}
+
 	// 创建主容器
 	mainContainer := container.NewVBox(
 		// 标题
 		container.NewPadded(title),
 
+		// 存档信息
+		worldInfoBox,
+
+		// 存档类型
+		container.NewVBox(
+			container.NewBorder(nil, nil, widget.NewLabel("存档类型"), nil, typeSelect),
+			typeDescription,
+		),
+
 		// 存档名称
 		container.NewVBox(
 			widget.NewLabel("存档名称"),
@@ -186,11 +329,66 @@ func newContent(window fyne.Window, info *database.Archive, mode OperationMode,
 			pathEntry,
 		),
 
-		layout.NewSpacer(),
-		container.NewPadded(buttonContainer),
+		// 附加路径
+		container.NewVBox(
+			widget.NewLabel("附加路径"),
+			layout.NewSpacer(),
+			extraPathEntry,
+		),
+
+		// 存储配额
+		container.NewVBox(
+			widget.NewLabel("存储配额 (GB)"),
+			layout.NewSpacer(),
+			quotaEntry,
+		),
+
+		// 排除规则
+		container.NewVBox(
+			container.NewBorder(nil, nil, widget.NewLabel("排除规则"), container.NewHBox(presetSelect, previewButton)),
+			excludeEntry,
+		),
+
+		// 规范化区域文件
+		container.NewVBox(
+			normalizeCheck,
+			normalizeTip,
+		),
+
+		// 完整性检查
+		container.NewVBox(
+			scanCheck,
+			scanTip,
+		),
 	)
 
-	return container.NewPadded(mainContainer)
+	return container.NewBorder(nil, container.NewPadded(buttonContainer), nil, nil,
+		container.NewVScroll(container.NewPadded(mainContainer)))
+}
+
+// parseQuota 解析配额输入 返回字节数
+func parseQuota(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	gb, err := strconv.ParseFloat(s, 64)
+	if err != nil || gb < 0 {
+		return 0, fmt.Errorf("存储配额必须是不小于 0 的数字")
+	}
+	return int64(gb * bytesPerGB), nil
+}
+
+// parseExtraPaths 解析附加路径 每行一个 忽略空行
+func parseExtraPaths(text string) []string {
+	var paths []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths
 }
 
 // 创建存档
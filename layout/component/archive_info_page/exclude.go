@@ -0,0 +1,74 @@
+package archive_info_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"strings"
+)
+
+// appendPreset 将预设中的规则追加到已有规则之后 已存在的规则不会重复添加
+func appendPreset(text, presetName string) string {
+	var patterns = archive.ParseExcludes(text)
+	var exists = make(map[string]bool, len(patterns))
+	for _, pattern := range patterns {
+		exists[pattern] = true
+	}
+
+	for _, preset := range archive.ExcludePresets {
+		if preset.Name != presetName {
+			continue
+		}
+		for _, pattern := range preset.Patterns {
+			if !exists[pattern] {
+				patterns = append(patterns, pattern)
+				exists[pattern] = true
+			}
+		}
+	}
+
+	return strings.Join(patterns, "\n")
+}
+
+// showExcludePreview 统计每条排除规则会排除多少数据 并在对话框中展示
+func showExcludePreview(window fyne.Window, roots []string, text string) {
+	var patterns = archive.ParseExcludes(text)
+	if len(patterns) == 0 {
+		dialog.NewInformation("排除规则预览", "还没有填写排除规则", window).Show()
+		return
+	}
+	for _, root := range roots {
+		if root == "" || !IsValidPathFormat(root) {
+			dialog.NewInformation("注意！", "请先填写正确的存档路径", window).Show()
+			return
+		}
+	}
+
+	var progress = dialog.NewCustomWithoutButtons("排除规则预览", widget.NewProgressBarInfinite(), window)
+	progress.Show()
+
+	go func() {
+		stats, err := archive.PreviewExcludes(roots, patterns)
+
+		fyne.Do(func() {
+			progress.Hide()
+			if err != nil {
+				dialog.NewInformation("预览失败", err.Error(), window).Show()
+				return
+			}
+
+			var lines = make([]string, 0, len(stats))
+			for _, stat := range stats {
+				lines = append(lines, fmt.Sprintf("%s\n    %d 个文件，%.2f MB", stat.Pattern, stat.Files, float64(stat.Bytes)/1048576))
+			}
+
+			var label = widget.NewLabel(strings.Join(lines, "\n"))
+			var content = container.NewVScroll(label)
+			content.SetMinSize(fyne.NewSize(320, 260))
+			dialog.NewCustom("排除规则预览", "关闭", content, window).Show()
+		})
+	}()
+}
@@ -0,0 +1,182 @@
+package chunk_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/layout/manage"
+	"minecraft-archive-backup/model/dto/database"
+	"sort"
+	"strings"
+)
+
+// NewDiffWindow 区块变化报告窗口 比较同一个存档的两个快照
+func NewDiffWindow(a *database.Archive) {
+	var window = manage.GetWindow()
+
+	// 标题
+	window.SetTitle(fmt.Sprintf("[ %s ] 区块变化", a.Name))
+
+	// 内容
+	window.SetContent(diffContent(a, window))
+
+	// 调整大小
+	window.Resize(fyne.NewSize(520, 600))
+
+	// 展示
+	window.Show()
+}
+
+func diffContent(a *database.Archive, window fyne.Window) fyne.CanvasObject {
+	records, err := archive.GetBackupRecordsByArchiveID(a.ID)
+	if err != nil || len(records) < 2 {
+		var message = "至少需要两个快照才能比较区块变化"
+		if err != nil {
+			message = err.Error()
+		}
+		return container.NewCenter(widget.NewLabel(message))
+	}
+
+	// 快照按时间排序 默认比较最近的两个快照
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	var options = make([]string, len(records))
+	for i, record := range records {
+		options[i] = recordLabel(&record)
+	}
+
+	fromSelect := widget.NewSelect(options, nil)
+	fromSelect.SetSelectedIndex(len(records) - 2)
+	toSelect := widget.NewSelect(options, nil)
+	toSelect.SetSelectedIndex(len(records) - 1)
+
+	summaryLabel := widget.NewLabel("选择两个快照后点击对比")
+	summaryLabel.Wrapping = fyne.TextWrapWord
+
+	// 区域汇总与区块列表
+	var diff = &archive.ChunkDiff{}
+	regionList := widget.NewList(
+		func() int { return len(diff.Regions) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			var r = diff.Regions[id]
+			object.(*widget.Label).SetText(fmt.Sprintf("%s  %s  新增 %d · 修改 %d · 删除 %d",
+				world.DimensionName(r.Dimension), r.Region, r.Created, r.Modified, r.Deleted))
+		},
+	)
+	chunkList := widget.NewList(
+		func() int { return len(diff.Changes) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			object.(*widget.Label).SetText(changeLabel(diff.Changes[id]))
+		},
+	)
+
+	var compareBtn *widget.Button
+	compareBtn = widget.NewButtonWithIcon("对比", theme.SearchIcon(), func() {
+		var from, to = fromSelect.SelectedIndex(), toSelect.SelectedIndex()
+		if from < 0 || to < 0 || from == to {
+			dialog.NewInformation("注意！", "请选择两个不同的快照", window).Show()
+			return
+		}
+		// 总是从旧快照比较到新快照
+		if from > to {
+			from, to = to, from
+		}
+
+		compareBtn.Disable()
+		summaryLabel.SetText("正在读取快照中的区域文件...")
+
+		go func() {
+			result, err := archive.DiffChunks(&records[from], &records[to])
+
+			fyne.Do(func() {
+				compareBtn.Enable()
+				if err != nil {
+					summaryLabel.SetText("对比失败")
+					dialog.NewInformation("对比失败", err.Error(), window).Show()
+					return
+				}
+
+				diff = result
+				summaryLabel.SetText(diffSummary(result))
+				regionList.Refresh()
+				chunkList.Refresh()
+			})
+		}()
+	})
+	compareBtn.Importance = widget.HighImportance
+
+	form := widget.NewForm(
+		widget.NewFormItem("旧快照", fromSelect),
+		widget.NewFormItem("新快照", toSelect),
+	)
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("区域汇总", regionList),
+		container.NewTabItem("区块列表", chunkList),
+	)
+
+	top := container.NewVBox(
+		form,
+		container.NewBorder(nil, nil, nil, compareBtn, summaryLabel),
+		widget.NewSeparator(),
+	)
+
+	return container.NewPadded(container.NewBorder(top, nil, nil, nil, tabs))
+}
+
+// recordLabel 快照的选项文本
+func recordLabel(record *database.BackupRecord) string {
+	var label = record.CreatedAt.Format("2006-01-02 15:04:05")
+	if record.Comment != "" {
+		label += " " + truncate(record.Comment, 12)
+	}
+	return label
+}
+
+// diffSummary 按维度统计区块变化
+func diffSummary(diff *archive.ChunkDiff) string {
+	if len(diff.Changes) == 0 {
+		return "两个快照之间没有区块发生变化"
+	}
+
+	var dimensions []string
+	var counts = make(map[string]*[3]int)
+	for _, change := range diff.Changes {
+		if counts[change.Dimension] == nil {
+			counts[change.Dimension] = &[3]int{}
+			dimensions = append(dimensions, change.Dimension)
+		}
+		counts[change.Dimension][change.Kind]++
+	}
+
+	var lines = make([]string, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		var c = counts[dimension]
+		lines = append(lines, fmt.Sprintf("%s: 新增 %d · 修改 %d · 删除 %d",
+			world.DimensionName(dimension), c[archive.ChunkCreated], c[archive.ChunkModified], c[archive.ChunkDeleted]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// changeLabel 单个区块变化的文本 包含方块坐标范围
+func changeLabel(change archive.ChunkChange) string {
+	minX, minZ, maxX, maxZ := change.BlockRange()
+	return fmt.Sprintf("[%s] %s 区块 (%d, %d)  方块 X %d~%d Z %d~%d",
+		change.Kind, world.DimensionName(change.Dimension), change.X, change.Z, minX, maxX, minZ, maxZ)
+}
+
+func truncate(s string, maxChars int) string {
+	var runes = []rune(s)
+	if len(runes) <= maxChars {
+		return s
+	}
+	return string(runes[:maxChars-1]) + "…"
+}
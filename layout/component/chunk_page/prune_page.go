@@ -0,0 +1,156 @@
+package chunk_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/layout/component/progress_page"
+	"minecraft-archive-backup/layout/manage"
+	"minecraft-archive-backup/model/dto/database"
+	"strconv"
+	"strings"
+)
+
+// NewPruneWindow 清理区块窗口 删除玩家几乎没有停留过的区块以减小存档体积
+// onFinished 清理完成后调用 用于刷新历史记录 (清理前会创建安全备份)
+func NewPruneWindow(a *database.Archive, onFinished func()) {
+	var window = manage.GetWindow()
+
+	// 标题
+	window.SetTitle(fmt.Sprintf("[ %s ] 清理区块", a.Name))
+
+	// 内容
+	window.SetContent(pruneContent(a, window, onFinished))
+
+	// 调整大小
+	window.Resize(fyne.NewSize(460, 600))
+
+	// 展示
+	window.Show()
+}
+
+func pruneContent(a *database.Archive, window fyne.Window, onFinished func()) fyne.CanvasObject {
+	minutesEntry := widget.NewEntry()
+	minutesEntry.SetText("1")
+
+	protectedEntry := widget.NewMultiLineEntry()
+	protectedEntry.SetPlaceHolder("每行一个方块坐标矩形 x1,z1,x2,z2\n例如 -200,-200,200,200 保护出生点附近的建筑")
+	protectedEntry.SetMinRowsVisible(4)
+
+	reportLabel := widget.NewLabel("点击预览统计会被删除的区块，不会修改存档")
+	reportLabel.Wrapping = fyne.TextWrapWord
+
+	var options = func() (archive.PruneOptions, error) {
+		minutes, err := strconv.ParseFloat(strings.TrimSpace(minutesEntry.Text), 64)
+		if err != nil || minutes <= 0 {
+			return archive.PruneOptions{}, fmt.Errorf("停留时间必须是大于 0 的数字")
+		}
+		protected, err := archive.ParseProtectedAreas(protectedEntry.Text)
+		if err != nil {
+			return archive.PruneOptions{}, err
+		}
+		return archive.PruneOptions{
+			MinInhabited: int64(minutes * archive.TicksPerMinute),
+			Protected:    protected,
+		}, nil
+	}
+
+	var previewBtn *widget.Button
+	previewBtn = widget.NewButtonWithIcon("预览", theme.SearchIcon(), func() {
+		options, err := options()
+		if err != nil {
+			dialog.NewInformation("注意！", err.Error(), window).Show()
+			return
+		}
+
+		previewBtn.Disable()
+		reportLabel.SetText("正在统计...")
+		go func() {
+			report, err := archive.PlanPrune(a, options)
+			fyne.Do(func() {
+				previewBtn.Enable()
+				if err != nil {
+					reportLabel.SetText("统计失败: " + err.Error())
+					return
+				}
+				reportLabel.SetText(pruneSummary(report))
+			})
+		}()
+	})
+
+	pruneBtn := widget.NewButtonWithIcon("执行清理", theme.DeleteIcon(), func() {
+		options, err := options()
+		if err != nil {
+			dialog.NewInformation("注意！", err.Error(), window).Show()
+			return
+		}
+		if err := archive.CheckArchiveNotInUse(a); err != nil {
+			dialog.NewInformation("注意！", err.Error(), window).Show()
+			return
+		}
+
+		manage.ShowConfirmInputDialog(&manage.ConfirmInputConfig{
+			Title:         "清理区块",
+			Message:       "将删除停留时间不足的区块，游戏下次经过时会重新生成\n清理前会自动创建安全备份",
+			ExpectedInput: "确认清理",
+			Placeholder:   "请输入确认清理",
+			ErrorTest:     "被删除区块中的建筑会丢失，请先设置保护区域",
+			Parent:        window,
+			Size:          fyne.Size{Width: 300, Height: 260},
+			Callback: func(input string, confirmed bool) {
+				if !confirmed {
+					return
+				}
+
+				var stdChan = archive.PruneChunks(a, options)
+				progress_page.NewWindow(a, progress_page.ModePrune, stdChan, func(success bool, errorMsg string, lastMessage *archive.BackupMessage) {
+					fyne.Do(func() {
+						if onFinished != nil {
+							onFinished()
+						}
+						if !success {
+							dialog.NewInformation("清理失败", errorMsg, window).Show()
+							return
+						}
+						dialog.NewInformation("清理完成", lastMessage.Message, window).Show()
+					})
+				})
+			},
+		})
+	})
+	pruneBtn.Importance = widget.DangerImportance
+
+	tip := widget.NewLabel("删除玩家累计停留时间不足的区块（例如飞行路过时生成的区块），保护区域内的区块不会被删除。请先退出存档或关闭服务器。")
+	tip.Wrapping = fyne.TextWrapWord
+
+	return container.NewPadded(container.NewVBox(
+		tip,
+		widget.NewForm(
+			widget.NewFormItem("停留少于 (分钟)", minutesEntry),
+		),
+		widget.NewLabel("保护区域"),
+		protectedEntry,
+		container.NewGridWithColumns(2, previewBtn, pruneBtn),
+		widget.NewSeparator(),
+		reportLabel,
+	))
+}
+
+// pruneSummary 按维度统计会被删除的区块
+func pruneSummary(report *archive.PruneReport) string {
+	if report.Chunks == 0 {
+		return "没有需要删除的区块"
+	}
+
+	var lines = []string{fmt.Sprintf("共删除 %d 个区块，节省约 %.2f MB", report.Chunks, float64(report.Bytes)/1048576)}
+	for _, dimension := range report.Dimensions {
+		lines = append(lines, fmt.Sprintf("%s: 删除 %d 个，保留 %d 个，%.2f MB",
+			world.DimensionName(dimension.Dimension), dimension.Chunks, dimension.Kept, float64(dimension.Bytes)/1048576))
+	}
+	return strings.Join(lines, "\n")
+}
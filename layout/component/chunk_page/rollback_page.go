@@ -0,0 +1,223 @@
+package chunk_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/layout/component/progress_page"
+	"minecraft-archive-backup/layout/manage"
+	"minecraft-archive-backup/model/dto/database"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 选择区块的方式
+const (
+	selectRect  = "方块坐标矩形"
+	selectChunk = "区块列表"
+)
+
+// NewRollbackWindow 区块回档窗口 只将快照中的部分区块写回存档
+// onFinished 回档完成后调用 用于刷新历史记录 (回档前会创建安全备份)
+func NewRollbackWindow(a *database.Archive, onFinished func()) {
+	var window = manage.GetWindow()
+
+	// 标题
+	window.SetTitle(fmt.Sprintf("[ %s ] 区块回档", a.Name))
+
+	// 内容
+	window.SetContent(rollbackContent(a, window, onFinished))
+
+	// 调整大小
+	window.Resize(fyne.NewSize(460, 560))
+
+	// 展示
+	window.Show()
+}
+
+func rollbackContent(a *database.Archive, window fyne.Window, onFinished func()) fyne.CanvasObject {
+	records, err := archive.GetBackupRecordsByArchiveID(a.ID)
+	if err != nil || len(records) == 0 {
+		var message = "还没有可用于回档的快照"
+		if err != nil {
+			message = err.Error()
+		}
+		return container.NewCenter(widget.NewLabel(message))
+	}
+
+	// 最新的快照在最前面
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+	var options = make([]string, len(records))
+	for i, record := range records {
+		options[i] = recordLabel(&record)
+	}
+
+	// 维度 选择快照后从快照中读取
+	var dimensions []string
+	dimensionSelect := widget.NewSelect(nil, nil)
+	dimensionSelect.PlaceHolder = "请先选择快照"
+
+	snapshotSelect := widget.NewSelect(options, nil)
+	snapshotSelect.OnChanged = func(string) {
+		var record = records[snapshotSelect.SelectedIndex()]
+		dimensionSelect.ClearSelected()
+		dimensionSelect.SetOptions(nil)
+		dimensionSelect.PlaceHolder = "正在读取维度..."
+		dimensionSelect.Disable()
+
+		go func() {
+			result, err := archive.SnapshotDimensions(record.SnapShot)
+			fyne.Do(func() {
+				dimensionSelect.Enable()
+				if err != nil {
+					dimensionSelect.PlaceHolder = "读取失败"
+					dimensionSelect.Refresh()
+					dialog.NewInformation("读取维度失败", err.Error(), window).Show()
+					return
+				}
+
+				dimensions = result
+				var names = make([]string, len(result))
+				for i, dimension := range result {
+					names[i] = world.DimensionName(dimension)
+				}
+				dimensionSelect.PlaceHolder = "选择维度"
+				dimensionSelect.SetOptions(names)
+				if len(names) > 0 {
+					dimensionSelect.SetSelectedIndex(0)
+				}
+			})
+		}()
+	}
+
+	// 方块坐标矩形
+	x1Entry, z1Entry := widget.NewEntry(), widget.NewEntry()
+	x2Entry, z2Entry := widget.NewEntry(), widget.NewEntry()
+	x1Entry.SetPlaceHolder("X1")
+	z1Entry.SetPlaceHolder("Z1")
+	x2Entry.SetPlaceHolder("X2")
+	z2Entry.SetPlaceHolder("Z2")
+	rectBox := container.NewGridWithColumns(2, x1Entry, z1Entry, x2Entry, z2Entry)
+
+	// 区块列表
+	chunkEntry := widget.NewMultiLineEntry()
+	chunkEntry.SetPlaceHolder("每行一个区块坐标，例如 -3,5")
+	chunkEntry.SetMinRowsVisible(5)
+	chunkEntry.Hide()
+
+	modeRadio := widget.NewRadioGroup([]string{selectRect, selectChunk}, func(mode string) {
+		if mode == selectChunk {
+			rectBox.Hide()
+			chunkEntry.Show()
+		} else {
+			chunkEntry.Hide()
+			rectBox.Show()
+		}
+	})
+	modeRadio.Horizontal = true
+	modeRadio.SetSelected(selectRect)
+
+	// 根据输入得到区块
+	var selectedChunks = func() ([]archive.ChunkPos, error) {
+		if modeRadio.Selected == selectChunk {
+			return archive.ParseChunkList(chunkEntry.Text)
+		}
+
+		var values [4]int
+		for i, entry := range []*widget.Entry{x1Entry, z1Entry, x2Entry, z2Entry} {
+			value, err := strconv.Atoi(strings.TrimSpace(entry.Text))
+			if err != nil {
+				return nil, fmt.Errorf("方块坐标必须是整数")
+			}
+			values[i] = value
+		}
+		return archive.ChunksInRect(values[0], values[1], values[2], values[3])
+	}
+
+	rollbackBtn := widget.NewButtonWithIcon("回档所选区块", theme.HistoryIcon(), func() {
+		var index = snapshotSelect.SelectedIndex()
+		if index < 0 {
+			dialog.NewInformation("注意！", "请选择快照", window).Show()
+			return
+		}
+		var dimensionIndex = dimensionSelect.SelectedIndex()
+		if dimensionIndex < 0 || dimensionIndex >= len(dimensions) {
+			dialog.NewInformation("注意！", "请选择维度", window).Show()
+			return
+		}
+		chunks, err := selectedChunks()
+		if err != nil {
+			dialog.NewInformation("注意！", err.Error(), window).Show()
+			return
+		}
+		if len(chunks) == 0 {
+			dialog.NewInformation("注意！", "没有选择任何区块", window).Show()
+			return
+		}
+
+		var record = records[index]
+		var dimension = dimensions[dimensionIndex]
+
+		var message = fmt.Sprintf("将%s的 %d 个区块回档到 %s\n回档前会自动创建安全备份",
+			world.DimensionName(dimension), len(chunks), record.CreatedAt.Format("2006年01月02日15:04:05"))
+		var size = fyne.Size{Width: 300, Height: 260}
+		// 不同版本的区块数据混在一起 降级时游戏无法读取
+		if warning := archive.RestoreVersionWarning(a, &record); warning != "" {
+			message, size = warning+"\n\n"+message, fyne.Size{Width: 400, Height: 340}
+		}
+
+		manage.ShowConfirmInputDialog(&manage.ConfirmInputConfig{
+			Title:         "区块回档",
+			Message:       message,
+			ExpectedInput: "确认回档",
+			Placeholder:   "请输入确认回档",
+			ErrorTest:     "所选区块中之后的改动都会丢失",
+			Parent:        window,
+			Size:          size,
+			Callback: func(input string, confirmed bool) {
+				if !confirmed {
+					return
+				}
+
+				var stdChan = archive.RollbackChunks(a, &record, dimension, chunks)
+				progress_page.NewWindow(a, progress_page.ModeChunks, stdChan, func(success bool, errorMsg string, lastMessage *archive.BackupMessage) {
+					fyne.Do(func() {
+						if onFinished != nil {
+							onFinished()
+						}
+						if !success {
+							dialog.NewInformation("区块回档失败", errorMsg, window).Show()
+							return
+						}
+						dialog.NewInformation("区块回档成功", lastMessage.Message, window).Show()
+					})
+				})
+			},
+		})
+	})
+	rollbackBtn.Importance = widget.HighImportance
+
+	tip := widget.NewLabel("只恢复所选区块的方块、实体与兴趣点数据，其他区块保持不变。请先退出存档或关闭服务器。")
+	tip.Wrapping = fyne.TextWrapWord
+
+	return container.NewPadded(container.NewVBox(
+		tip,
+		widget.NewForm(
+			widget.NewFormItem("快照", snapshotSelect),
+			widget.NewFormItem("维度", dimensionSelect),
+		),
+		modeRadio,
+		rectBox,
+		chunkEntry,
+		widget.NewSeparator(),
+		container.NewCenter(rollbackBtn),
+	))
+}
@@ -0,0 +1,119 @@
+package export_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/layout/component/progress_page"
+	"minecraft-archive-backup/layout/manage"
+	"minecraft-archive-backup/model/dto/database"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NewWindow 导出快照窗口 将快照中的世界导出为可以分享的 zip
+func NewWindow(a *database.Archive, record *database.BackupRecord) {
+	var window = manage.GetWindow()
+
+	// 标题
+	window.SetTitle(fmt.Sprintf("[ %s ] 导出快照", a.Name))
+
+	// 内容
+	window.SetContent(exportContent(a, record, window))
+
+	// 调整大小
+	window.Resize(fyne.NewSize(460, 360))
+
+	// 展示
+	window.Show()
+}
+
+func exportContent(a *database.Archive, record *database.BackupRecord, window fyne.Window) fyne.CanvasObject {
+	targetEntry := widget.NewEntry()
+	targetEntry.SetText(defaultTarget(a, record))
+
+	passwordEntry := widget.NewPasswordEntry()
+	passwordEntry.SetPlaceHolder("留空则不加密")
+
+	skipPlayerCheck := widget.NewCheck("不包含玩家数据 (playerdata、stats、advancements)", nil)
+
+	exportBtn := widget.NewButtonWithIcon("导出", theme.DocumentSaveIcon(), func() {
+		var target = strings.TrimSpace(targetEntry.Text)
+		if target == "" || !strings.EqualFold(filepath.Ext(target), ".zip") {
+			dialog.NewInformation("注意！", "请填写以 .zip 结尾的保存路径", window).Show()
+			return
+		}
+		if info, err := os.Stat(filepath.Dir(target)); err != nil || !info.IsDir() {
+			dialog.NewInformation("注意！", "保存路径所在的文件夹不存在", window).Show()
+			return
+		}
+
+		var export = func() {
+			var stdChan = archive.ExportSnapshot(a, record, target, archive.ExportOptions{
+				Password:       passwordEntry.Text,
+				SkipPlayerData: skipPlayerCheck.Checked,
+			})
+			progress_page.NewWindow(a, progress_page.ModeExport, stdChan, func(success bool, errorMsg string, lastMessage *archive.BackupMessage) {
+				fyne.Do(func() {
+					if !success {
+						dialog.NewInformation("导出失败", errorMsg, window).Show()
+						return
+					}
+					dialog.NewInformation("导出完成", lastMessage.Message, window).Show()
+				})
+			})
+		}
+
+		if _, err := os.Stat(target); err == nil {
+			dialog.NewConfirm("文件已存在", "是否覆盖 "+filepath.Base(target)+"？", func(ok bool) {
+				if ok {
+					export()
+				}
+			}, window).Show()
+			return
+		}
+		export()
+	})
+	exportBtn.Importance = widget.HighImportance
+
+	// 基岩版的世界位于 minecraftWorlds 中
+	var saves = "saves"
+	if archive.NormalizeArchiveType(a.Type) == database.ArchiveTypeBedrock {
+		saves = "minecraftWorlds"
+	}
+	tip := widget.NewLabel(fmt.Sprintf("导出 %s 的快照。压缩包的根目录为世界文件夹，解压到 %s 或通过启动器导入即可游玩。密码使用 ZipCrypto 加密，Windows 可以直接打开，但强度较低。",
+		record.CreatedAt.Format("2006年01月02日15:04:05"), saves))
+	tip.Wrapping = fyne.TextWrapWord
+
+	return container.NewPadded(container.NewVBox(
+		tip,
+		widget.NewForm(
+			widget.NewFormItem("保存到", targetEntry),
+			widget.NewFormItem("密码", passwordEntry),
+		),
+		skipPlayerCheck,
+		exportBtn,
+	))
+}
+
+// defaultTarget 默认保存到下载文件夹 文件名为存档名称与快照时间
+func defaultTarget(a *database.Archive, record *database.BackupRecord) string {
+	var name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\/:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, a.Name)
+	var file = fmt.Sprintf("%s_%s.zip", name, record.CreatedAt.Format("20060102_150405"))
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return file
+	}
+	return filepath.Join(home, "Downloads", file)
+}
@@ -3,15 +3,22 @@ package history_page
 import (
 	"fmt"
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/layout/component/chunk_page"
+	"minecraft-archive-backup/layout/component/export_page"
+	"minecraft-archive-backup/layout/component/import_page"
+	"minecraft-archive-backup/layout/component/item_page"
+	"minecraft-archive-backup/layout/component/map_page"
+	"minecraft-archive-backup/layout/component/player_page"
 	"minecraft-archive-backup/layout/component/progress_page"
 	"minecraft-archive-backup/layout/manage"
+	"minecraft-archive-backup/layout/resource/icon"
 	"minecraft-archive-backup/model/dto/database"
-	"path/filepath"
 	"unicode/utf8"
 )
 
@@ -26,8 +33,12 @@ func NewWindow(a *database.Archive) {
 	// 内容
 	window.SetContent(content(a, window))
 
-	// 调整大小
-	window.Resize(fyne.Size{Width: 430, Height: 500})
+	// 调整大小 可以预览地图的存档在卡片左侧显示缩略图
+	var width float32 = 480
+	if canPreviewMap(a) {
+		width += thumbnailSize
+	}
+	window.Resize(fyne.Size{Width: width, Height: 500})
 
 	// 展示
 	window.Show()
@@ -35,7 +46,11 @@ func NewWindow(a *database.Archive) {
 
 func content(a *database.Archive, window fyne.Window) *fyne.Container {
 	// 创建卡片容器
-	grid := container.NewGridWrap(fyne.Size{Width: 400, Height: 120})
+	var cardSize = fyne.Size{Width: 450, Height: 120}
+	if canPreviewMap(a) {
+		cardSize.Width += thumbnailSize
+	}
+	grid := container.NewGridWrap(cardSize)
 
 	// 创建滚动容器
 	var scrollContainer = container.NewScroll(container.NewPadded(grid))
@@ -45,8 +60,42 @@ func content(a *database.Archive, window fyne.Window) *fyne.Container {
 		refreshCards(a, window, grid, scrollContainer)
 	})
 
+	// 导入以前的 zip 或文件夹备份
+	importBtn := widget.NewButtonWithIcon("导入备份", theme.DownloadIcon(), func() {
+		import_page.NewBackupWindow(a, func() {
+			refreshCards(a, window, grid, scrollContainer)
+		})
+	})
+
+	// Java 版的世界与服务器可以查看区块变化 只回档部分区块或单个玩家 搜索物品以及清理区块
+	var topBar fyne.CanvasObject = container.NewGridWithColumns(2, refreshBtn, importBtn)
+	if t := archive.NormalizeArchiveType(a.Type); t == database.ArchiveTypeWorld || t == database.ArchiveTypeServer {
+		chunkDiffBtn := widget.NewButtonWithIcon("区块变化", theme.GridIcon(), func() {
+			chunk_page.NewDiffWindow(a)
+		})
+		chunkRollbackBtn := widget.NewButtonWithIcon("区块回档", theme.HistoryIcon(), func() {
+			chunk_page.NewRollbackWindow(a, func() {
+				refreshCards(a, window, grid, scrollContainer)
+			})
+		})
+		playerRollbackBtn := widget.NewButtonWithIcon("玩家回档", theme.AccountIcon(), func() {
+			player_page.NewWindow(a, func() {
+				refreshCards(a, window, grid, scrollContainer)
+			})
+		})
+		itemSearchBtn := widget.NewButtonWithIcon("物品搜索", theme.SearchIcon(), func() {
+			item_page.NewWindow(a)
+		})
+		chunkPruneBtn := widget.NewButtonWithIcon("清理区块", theme.ContentCutIcon(), func() {
+			chunk_page.NewPruneWindow(a, func() {
+				refreshCards(a, window, grid, scrollContainer)
+			})
+		})
+		topBar = container.NewGridWithColumns(4, refreshBtn, importBtn, chunkDiffBtn, chunkRollbackBtn, playerRollbackBtn, itemSearchBtn, chunkPruneBtn)
+	}
+
 	// 创建主容器
-	mainContainer := container.NewBorder(refreshBtn, nil, nil, nil, scrollContainer)
+	mainContainer := container.NewBorder(topBar, nil, nil, nil, scrollContainer)
 
 	// 初始加载卡片
 	refreshCards(a, window, grid, scrollContainer)
@@ -107,21 +156,49 @@ func refreshCards(a *database.Archive, window fyne.Window, grid *fyne.Container,
 		})
 		deleteBtn.Importance = widget.DangerImportance
 
+		// 固定按钮 固定的快照不允许删除
+		var pinIcon fyne.Resource = theme.NewThemedResource(icon.LockOpenSvg)
+		if record.Pinned {
+			pinIcon = theme.NewThemedResource(icon.LockSvg)
+			deleteBtn.Disable()
+		}
+		pinBtn := widget.NewButtonWithIcon("", pinIcon, func() {
+			var pinned = !record.Pinned
+			if err := archive.SetBackupRecordPinned(&record, pinned); err != nil {
+				dialog.NewInformation("修改固定状态失败", err.Error(), window).Show()
+				return
+			}
+			refreshCards(a, window, grid, scrollContainer)
+		})
+
 		restoreBtn := widget.NewButtonWithIcon("快照回档", theme.ViewRefreshIcon(), func() {
-			fmt.Println(filepath.Join(a.Path, "session.lock"))
-			if ok, _ := IsWorldInUse(filepath.Join(a.Path, "session.lock")); ok {
-				dialog.NewInformation("注意", "如果您要回档,您需要退出当前地图(无需退出游戏)", window).Show()
+			// 世界与服务器需要先退出 实例与文件夹没有 session.lock
+			if ok, err := archive.IsArchiveInUse(a); ok {
+				var message = "如果您要回档,您需要退出当前地图(无需退出游戏)"
+				if archive.NormalizeArchiveType(a.Type) == database.ArchiveTypeServer {
+					message = "如果您要回档,您需要先关闭服务器"
+				}
+				if err != nil {
+					message = err.Error()
+				}
+				dialog.NewInformation("注意", message, window).Show()
 				return
 			}
 
+			// 回档会改变游戏版本时提示 尤其是降级
+			var message, size = "您确认要进行回档吗？", fyne.Size{Width: 250, Height: 250}
+			if warning := archive.RestoreVersionWarning(a, &record); warning != "" {
+				message, size = warning+"\n\n您确认要进行回档吗？", fyne.Size{Width: 400, Height: 330}
+			}
+
 			manage.ShowConfirmInputDialog(&manage.ConfirmInputConfig{
 				Title:         "回档指定快照",
-				Message:       "您确认要进行回档吗？",
+				Message:       message,
 				ExpectedInput: "确认回档",
 				Placeholder:   "请输入确认回档",
 				ErrorTest:     "请注意！回档的操作是不可逆的，务必谨慎！",
 				Parent:        window,
-				Size:          fyne.Size{Width: 250, Height: 250},
+				Size:          size,
 				Callback: func(input string, confirmed bool) {
 					if confirmed {
 						// 进行回档
@@ -129,7 +206,7 @@ func refreshCards(a *database.Archive, window fyne.Window, grid *fyne.Container,
 						// 将通道和存档信息传入备份页面
 						progress_page.NewWindow(a, 1, stdChan, func(success bool, errorMsg string, lastMessage *archive.BackupMessage) {
 							if !success {
-								dialog.NewInformation("回档失败", err.Error(), window).Show()
+								dialog.NewInformation("回档失败", errorMsg, window).Show()
 								return
 							} else {
 								dialog.NewInformation("回档成功", "回档成功啦，感谢您的使用！", window).Show()
@@ -147,27 +224,72 @@ func refreshCards(a *database.Archive, window fyne.Window, grid *fyne.Container,
 		infoBtn := widget.NewButtonWithIcon("存档信息", theme.ListIcon(), func() {
 			var rawData, _ = archive.ResticRawData(record.SnapShot)
 			var RestoreSize, _ = archive.ResticRestoreSize(record.SnapShot)
-			dialog.NewInformation("存档详细信息",
-				fmt.Sprintf(`存档备注：%s
+			var detail = fmt.Sprintf(`存档备注：%s
 创建时间：%s
 快照ID ：%s
+父快照ID：%s
 存储占用：%dMB
 原始大小：%dMB
-`, record.Comment, record.CreatedAt.Format("2006年01月02日15:04:05"), record.SnapShot[:8], rawData.TotalSize/1048576, RestoreSize.TotalSize/1048576),
-				window,
-			).Show()
+新增数据：%.2fMB
+备份耗时：%.1f秒
+`, record.Comment, record.CreatedAt.Format("2006年01月02日15:04:05"), record.SnapShot[:8], shortSnapshot(record.Parent),
+				rawData.TotalSize/1048576, RestoreSize.TotalSize/1048576, float64(record.DataAdded)/1048576, record.Duration)
+			if record.DataVersion != 0 {
+				detail += fmt.Sprintf("游戏版本：%s (数据版本 %d)\n", archive.VersionLabel(record.DataVersion, record.VersionName), record.DataVersion)
+			}
+			if record.Normalized {
+				detail += "区域文件：备份前已规范化\n"
+			}
+			// 备份前的完整性检查
+			if record.Health != database.HealthUnknown {
+				detail += fmt.Sprintf("存档完整性：%s\n%s\n", archive.HealthName(record.Health), record.HealthReport)
+			}
+			showRecordInfo(&record, detail, window)
 		})
 		infoBtn.Importance = widget.WarningImportance
 
+		var buttons = container.NewHBox(pinBtn, deleteBtn, infoBtn, restoreBtn)
+
+		// 世界可以导出为可以分享的 zip
+		if t := archive.NormalizeArchiveType(a.Type); t == database.ArchiveTypeWorld || t == database.ArchiveTypeServer || t == database.ArchiveTypeBedrock {
+			exportBtn := widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() {
+				export_page.NewWindow(a, &record)
+			})
+			buttons.Objects = append([]fyne.CanvasObject{exportBtn}, buttons.Objects...)
+		}
+
+		// Java 版的世界与服务器可以预览快照的俯视地图 卡片中显示出生点附近的缩略图 其他范围在地图预览中选择
+		if canPreviewMap(a) {
+			mapBtn := widget.NewButtonWithIcon("", theme.MediaPhotoIcon(), func() {
+				map_page.NewWindow(a, &record, func() {
+					refreshCards(a, window, grid, scrollContainer)
+				})
+			})
+			buttons.Objects = append([]fyne.CanvasObject{mapBtn}, buttons.Objects...)
+		}
+
 		// 创建卡片
+		var cardTitle = formattedTime
+		if record.Pinned {
+			cardTitle += " (已固定)"
+		}
+		cardTitle += healthBadge(record.Health)
+		var subtitle = record.Comment
+		if record.DataVersion != 0 {
+			subtitle = "[" + archive.VersionLabel(record.DataVersion, record.VersionName) + "] " + subtitle
+		}
 		card := widget.NewCard(
-			formattedTime,
-			truncateWithEllipsis(record.Comment, 27),
-			container.NewHBox(deleteBtn, infoBtn, restoreBtn),
+			cardTitle,
+			truncateWithEllipsis(subtitle, 27),
+			buttons,
 		)
 
 		// 将卡片添加到网格中
-		grid.Add(card)
+		if canPreviewMap(a) {
+			grid.Add(container.NewBorder(nil, nil, mapThumbnail(&record), nil, card))
+		} else {
+			grid.Add(card)
+		}
 	}
 
 	// 刷新容器显示
@@ -179,6 +301,38 @@ func refreshCards(a *database.Archive, window fyne.Window, grid *fyne.Container,
 	})
 }
 
+// thumbnailSize 卡片中地图缩略图的边长
+const thumbnailSize = 110
+
+// canPreviewMap Java 版的世界与服务器可以预览快照的俯视地图
+func canPreviewMap(a *database.Archive) bool {
+	var t = archive.NormalizeArchiveType(a.Type)
+	return t == database.ArchiveTypeWorld || t == database.ArchiveTypeServer
+}
+
+// mapThumbnail 快照出生点附近的缓存预览图 没有缓存时留空 在地图预览中渲染后显示
+func mapThumbnail(record *database.BackupRecord) fyne.CanvasObject {
+	var image = canvas.NewImageFromResource(nil)
+	if p := archive.CachedMapPreview(record, archive.MapArea{Spawn: true, Radius: archive.DefaultPreviewRadius}); p != "" {
+		image = canvas.NewImageFromFile(p)
+	}
+	image.FillMode = canvas.ImageFillContain
+	image.ScaleMode = canvas.ImageScalePixels
+	image.SetMinSize(fyne.NewSquareSize(thumbnailSize - theme.Padding()))
+	return image
+}
+
+// shortSnapshot 快照ID的短格式 没有父快照时显示"无"
+func shortSnapshot(id string) string {
+	if id == "" {
+		return "无"
+	}
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
 func truncateWithEllipsis(s string, maxChars int) string {
 	if utf8.RuneCountInString(s) <= maxChars {
 		return s
@@ -187,3 +341,16 @@ func truncateWithEllipsis(s string, maxChars int) string {
 	runes := []rune(s)
 	return string(runes[:maxChars-1]) + "…" // 使用省略号
 }
+
+// healthBadge 备份前完整性检查结果的标记 没有检查时为空
+func healthBadge(health database.HealthStatus) string {
+	switch health {
+	case database.HealthHealthy:
+		return " ✓"
+	case database.HealthWarnings:
+		return " ⚠" + archive.HealthName(health)
+	case database.HealthCorrupt:
+		return " ✗" + archive.HealthName(health)
+	}
+	return ""
+}
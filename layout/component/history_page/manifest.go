@@ -0,0 +1,91 @@
+package history_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/model/dto/database"
+	"strings"
+)
+
+// showRecordInfo 显示备份记录的详细信息 带有模组清单时一并显示清单与上一个快照的差异
+func showRecordInfo(record *database.BackupRecord, detail string, window fyne.Window) {
+	manifest, err := archive.ParseManifest(record)
+	if err != nil || manifest == nil {
+		dialog.NewInformation("存档详细信息", detail, window).Show()
+		return
+	}
+
+	if manifest.Loader != "" {
+		detail += fmt.Sprintf("加载器：%s\n", manifest.Loader)
+	}
+
+	var mods = make([]string, 0, len(manifest.Mods))
+	for _, mod := range manifest.Mods {
+		mods = append(mods, archive.ModLabel(mod))
+	}
+
+	var accordion = widget.NewAccordion(
+		widget.NewAccordionItem(fmt.Sprintf("模组 (%d)", len(mods)), listLabel(mods)),
+		widget.NewAccordionItem(fmt.Sprintf("数据包 (%d)", len(manifest.Datapacks)), listLabel(manifest.Datapacks)),
+	)
+	if previous, err := archive.PreviousManifestRecord(record); err == nil && previous != nil {
+		if old, err := archive.ParseManifest(previous); err == nil {
+			var title = "与 " + previous.CreatedAt.Format("2006年01月02日15:04:05") + " 的快照相比"
+			accordion.Append(widget.NewAccordionItem(title, widget.NewLabel(diffText(old, manifest))))
+		}
+	}
+
+	var content = container.NewVScroll(container.NewVBox(widget.NewLabel(detail), accordion))
+	content.SetMinSize(fyne.NewSize(380, 360))
+	dialog.NewCustom("存档详细信息", "关闭", content, window).Show()
+}
+
+// listLabel 每行一项的标签
+func listLabel(items []string) *widget.Label {
+	if len(items) == 0 {
+		return widget.NewLabel("无")
+	}
+	return widget.NewLabel(strings.Join(items, "\n"))
+}
+
+// diffText 清单差异的文字描述
+func diffText(old, current *world.ModManifest) string {
+	var diff = archive.DiffManifests(old, current)
+	if diff.Empty() {
+		return "模组、数据包与加载器都没有变化"
+	}
+
+	var lines []string
+	if diff.OldLoader != diff.NewLoader {
+		lines = append(lines, fmt.Sprintf("加载器：%s → %s", orNone(diff.OldLoader), orNone(diff.NewLoader)))
+	}
+	var sections = []struct {
+		prefix string
+		items  []string
+	}{
+		{"+ 模组 ", diff.AddedMods},
+		{"- 模组 ", diff.RemovedMods},
+		{"* 模组 ", diff.UpdatedMods},
+		{"+ 数据包 ", diff.AddedPacks},
+		{"- 数据包 ", diff.RemovedPacks},
+	}
+	for _, section := range sections {
+		for _, item := range section.items {
+			lines = append(lines, section.prefix+item)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// orNone 空字符串显示为 无
+func orNone(s string) string {
+	if s == "" {
+		return "无"
+	}
+	return s
+}
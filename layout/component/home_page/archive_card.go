@@ -3,14 +3,17 @@ package home_page
 import (
 	"fmt"
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/internal/world"
 	"minecraft-archive-backup/layout/component/archive_info_page"
 	"minecraft-archive-backup/layout/component/history_page"
 	"minecraft-archive-backup/layout/component/progress_page"
+	"minecraft-archive-backup/layout/component/world_info"
 	"minecraft-archive-backup/layout/manage"
 	"minecraft-archive-backup/model/dto/database"
 	"strings"
@@ -35,12 +38,21 @@ func NewCard(a *database.Archive, window fyne.Window) *ArchiveCard {
 			Callback: func(input string, confirmed bool) {
 				// 输入正确 执行删除操作
 				if confirmed {
+					// 存在固定的快照时 不允许删除整个存档
+					if count, err := archive.CountPinnedBackupRecords(a.ID); err != nil {
+						dialog.NewInformation("查询固定快照失败", err.Error(), window).Show()
+						return
+					} else if count > 0 {
+						dialog.NewInformation("无法删除存档", fmt.Sprintf("[ %s ]有 %d 个已固定的快照，请先取消固定", a.Name, count), window).Show()
+						return
+					}
+
 					// 删除 restic 中相关的快照
 					if records, err := archive.GetBackupRecordsByArchiveID(a.ID); err != nil {
 						dialog.NewInformation(fmt.Sprintf("获取[ %s ]备份记录失败", a.Name), err.Error(), window).Show()
 						return
 					} else {
-						var snapShots = make([]string, len(records))
+						var snapShots = make([]string, 0, len(records))
 						for _, r := range records {
 							snapShots = append(snapShots, r.SnapShot)
 						}
@@ -98,22 +110,32 @@ func NewCard(a *database.Archive, window fyne.Window) *ArchiveCard {
 				// 获取用户输入的备注
 				comment := strings.TrimSpace(entry.Text)
 
+				// 以最近一次成功的备份作为父快照
+				var parent string
+				if latest, err := archive.GetLatestBackupRecordByArchiveID(a.ID); err != nil {
+					dialog.NewInformation("查询父快照失败", err.Error(), window).Show()
+					return
+				} else if latest != nil {
+					parent = latest.SnapShot
+				}
+
 				// 执行备份
-				var stdChan = archive.ResticBackup(a)
+				var stdChan = archive.BackupArchive(a, parent)
 
 				// 将通道和存档信息传入备份页面
 				progress_page.NewWindow(a, 0, stdChan, func(success bool, errorMsg string, lastMessage *archive.BackupMessage) {
 					if success {
 						// 创建一个备份记录
-						var err = archive.CreateBackupRecord(&database.BackupRecord{
-							ArchiveID: a.ID,
-							SnapShot:  lastMessage.SnapshotID,
-							Comment:   comment,
-						})
+						result, err := archive.FinishBackup(a, parent, comment, lastMessage)
 						if err != nil {
 							dialog.NewInformation("快照ID写入到sqlite失败", err.Error(), window).Show()
 							return
 						}
+
+						// 存档没有变化 没有创建新的快照
+						if result.Skipped {
+							dialog.NewInformation("存档没有变化", unchangedMessage(result.Parent), window).Show()
+						}
 					}
 				})
 			},
@@ -124,18 +146,77 @@ func NewCard(a *database.Archive, window fyne.Window) *ArchiveCard {
 	})
 	backupBtn.Importance = widget.HighImportance
 
+	// 存档信息 读取失败时不影响卡片的其他功能
+	worldInfo, err := archive.GetWorldInfo(a)
+	if err != nil {
+		fmt.Println(err)
+	}
+
 	return &ArchiveCard{
 		archiveInfo: a,
 		content: widget.NewCard(truncateWithEllipsis(a.Name, 15), truncateWithEllipsis(a.Comment, 27),
-			container.NewHBox(
-				deleteBtn,
-				editBtn,
-				historyBtn,
-				backupBtn,
+			container.NewVBox(
+				cardInfo(a, worldInfo),
+				container.NewHBox(
+					deleteBtn,
+					editBtn,
+					historyBtn,
+					backupBtn,
+				),
 			)),
 	}
 }
 
+// cardInfo 卡片上按存档类型展示的信息
+func cardInfo(a *database.Archive, worldInfo *database.WorldInfo) fyne.CanvasObject {
+	var image fyne.CanvasObject
+	var summary, detail string
+
+	switch archive.NormalizeArchiveType(a.Type) {
+	case database.ArchiveTypeWorld, database.ArchiveTypeBedrock:
+		image = world_info.NewIcon(worldInfo, 48)
+		summary = world_info.Summary(worldInfo)
+		detail = world_info.LastPlayed(worldInfo)
+	case database.ArchiveTypeServer:
+		image = world_info.NewIcon(worldInfo, 48)
+		summary = "服务器 · " + world_info.Summary(worldInfo)
+		detail = world_info.LastPlayed(worldInfo)
+	case database.ArchiveTypeInstance:
+		image = typeIcon(theme.ComputerIcon())
+		summary = fmt.Sprintf("游戏实例 · %d 个模组", world.CountMods(a.Path))
+		detail = a.Path
+	default:
+		image = typeIcon(theme.FolderIcon())
+		summary = fmt.Sprintf("文件夹 · %d 个路径", len(a.BackupPaths()))
+		detail = a.Path
+	}
+
+	return container.NewBorder(nil, nil, image, nil,
+		container.NewVBox(
+			widget.NewLabel(truncateWithEllipsis(summary, 30)),
+			widget.NewLabel(truncateWithEllipsis(detail, 30)),
+		),
+	)
+}
+
+// typeIcon 没有世界图标的存档类型 使用主题图标
+func typeIcon(resource fyne.Resource) fyne.CanvasObject {
+	image := canvas.NewImageFromResource(resource)
+	image.FillMode = canvas.ImageFillContain
+	image.SetMinSize(fyne.NewSize(48, 48))
+	return image
+}
+
+// unchangedMessage 存档没有变化时的提示
+func unchangedMessage(parent string) string {
+	record, err := archive.GetBackupRecordBySnapShot(parent)
+	if err != nil || record == nil {
+		return "存档自上次备份以来没有任何变化，未创建新的快照"
+	}
+	return fmt.Sprintf("存档自 %s 的快照 %s 以来没有任何变化，未创建新的快照",
+		record.CreatedAt.Format("2006年01月02日15:04:05"), record.SnapShot[:8])
+}
+
 func truncateWithEllipsis(s string, maxChars int) string {
 	if utf8.RuneCountInString(s) <= maxChars {
 		return s
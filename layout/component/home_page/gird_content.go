@@ -7,7 +7,7 @@ import (
 	"sort"
 )
 
-var girdContainer = container.NewGridWrap(fyne.Size{Width: 400, Height: 120})
+var girdContainer = container.NewGridWrap(fyne.Size{Width: 400, Height: 230})
 
 // refreshCard 在 home 页面 会进行赋值 并传入 window 参数
 var refreshCard func()
@@ -9,6 +9,8 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"image/color"
 	"minecraft-archive-backup/layout/component/archive_info_page"
+	"minecraft-archive-backup/layout/component/import_page"
+	"minecraft-archive-backup/layout/component/setting_page"
 	"minecraft-archive-backup/layout/resource/icon"
 	"minecraft-archive-backup/model/dto/database"
 )
@@ -31,12 +33,20 @@ func topContent() *fyne.Container {
 	topCreateArchiveBackupButton := widget.NewButtonWithIcon("创建存档", theme.DocumentCreateIcon(), createArchiveBackup)
 	topCreateArchiveBackupButton.Importance = widget.HighImportance
 
+	topImportButton := widget.NewButtonWithIcon("导入存档", theme.DownloadIcon(), func() {
+		import_page.NewWindow(refreshCard)
+	})
+
+	topSettingButton := widget.NewButtonWithIcon("", theme.SettingsIcon(), func() {
+		setting_page.NewWindow(refreshCard)
+	})
+
 	// 顶部容器
 	topContainer := container.NewPadded(
 		container.NewBorder(
 			nil, nil,
 			titleWithIcon,
-			topCreateArchiveBackupButton,
+			container.NewHBox(topSettingButton, topImportButton, topCreateArchiveBackupButton),
 		),
 	)
 	return topContainer
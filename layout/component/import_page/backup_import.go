@@ -0,0 +1,127 @@
+package import_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/layout/component/progress_page"
+	"minecraft-archive-backup/layout/manage"
+	"minecraft-archive-backup/model/dto/database"
+	"path/filepath"
+	"strings"
+)
+
+// NewBackupWindow 导入旧备份窗口 将以前的 zip 或文件夹按原来的时间加入备份历史
+// onFinished 导入完成后调用 用于刷新历史记录
+func NewBackupWindow(a *database.Archive, onFinished func()) {
+	var window = manage.GetWindow()
+
+	// 标题
+	window.SetTitle(fmt.Sprintf("[ %s ] 导入备份", a.Name))
+
+	// 内容
+	window.SetContent(backupContent(a, window, onFinished))
+
+	// 调整大小
+	window.Resize(fyne.NewSize(520, 600))
+
+	// 展示
+	window.Show()
+}
+
+func backupContent(a *database.Archive, window fyne.Window, onFinished func()) fyne.CanvasObject {
+	pathsEntry := widget.NewMultiLineEntry()
+	pathsEntry.SetPlaceHolder("每行一个 zip 压缩包或文件夹的路径\n例如 Minecraft 备份世界生成的 2023-05-01_12-30-45_新的世界.zip")
+	pathsEntry.SetMinRowsVisible(5)
+
+	planLabel := widget.NewLabel("点击读取时间，按时间先后列出将要导入的备份")
+	planLabel.Wrapping = fyne.TextWrapWord
+
+	// 读取时间后才能导入 修改路径后需要重新读取
+	var items []archive.ImportItem
+	var importBtn *widget.Button
+	pathsEntry.OnChanged = func(string) {
+		items = nil
+		importBtn.Disable()
+	}
+
+	var planBtn *widget.Button
+	planBtn = widget.NewButtonWithIcon("读取时间", theme.SearchIcon(), func() {
+		var paths []string
+		for _, line := range strings.Split(pathsEntry.Text, "\n") {
+			if line = strings.Trim(strings.TrimSpace(line), `"`); line != "" {
+				paths = append(paths, line)
+			}
+		}
+		if len(paths) == 0 {
+			dialog.NewInformation("注意！", "请填写要导入的压缩包或文件夹", window).Show()
+			return
+		}
+
+		planBtn.Disable()
+		planLabel.SetText("正在读取...")
+		go func() {
+			planned, err := archive.PlanImport(a, paths)
+			fyne.Do(func() {
+				planBtn.Enable()
+				if err != nil {
+					planLabel.SetText("读取失败: " + err.Error())
+					return
+				}
+				items = planned
+				planLabel.SetText(planText(planned))
+				importBtn.Enable()
+			})
+		}()
+	})
+
+	importBtn = widget.NewButtonWithIcon("开始导入", theme.DownloadIcon(), func() {
+		if len(items) == 0 {
+			return
+		}
+
+		var stdChan = archive.ImportBackups(a, items)
+		progress_page.NewWindow(a, progress_page.ModeImport, stdChan, func(success bool, errorMsg string, lastMessage *archive.BackupMessage) {
+			fyne.Do(func() {
+				if onFinished != nil {
+					onFinished()
+				}
+				if !success {
+					dialog.NewInformation("导入失败", errorMsg, window).Show()
+					return
+				}
+				dialog.NewInformation("导入完成", lastMessage.Message, window).Show()
+			})
+		})
+	})
+	importBtn.Importance = widget.HighImportance
+	importBtn.Disable()
+
+	tip := widget.NewLabel("依次从文件名、level.dat 的最后游玩时间或文件的修改时间中读取备份时间，按时间先后备份，历史记录使用原来的时间并带有“导入”备注。存档本身不会被修改。")
+	tip.Wrapping = fyne.TextWrapWord
+
+	return container.NewBorder(
+		container.NewPadded(container.NewVBox(
+			tip,
+			pathsEntry,
+			container.NewGridWithColumns(2, planBtn, importBtn),
+			widget.NewSeparator(),
+		)),
+		nil, nil, nil,
+		container.NewVScroll(container.NewPadded(planLabel)),
+	)
+}
+
+// planText 将要导入的备份 每行一个
+func planText(items []archive.ImportItem) string {
+	var lines = []string{fmt.Sprintf("共 %d 个备份：", len(items))}
+	for _, item := range items {
+		lines = append(lines, fmt.Sprintf("%s (%s)  %s",
+			item.Time.Format("2006年01月02日15:04:05"), item.TimeSource, filepath.Base(item.Path)))
+	}
+	return strings.Join(lines, "\n")
+}
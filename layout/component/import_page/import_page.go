@@ -0,0 +1,185 @@
+package import_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/layout/manage"
+)
+
+// NewWindow 导入存档向导 扫描常见启动器中的存档 勾选后批量创建
+func NewWindow(refreshCallback func()) {
+	var window = manage.GetWindow()
+
+	// 标题
+	window.SetTitle("导入存档")
+
+	// 内容
+	window.SetContent(content(window, refreshCallback))
+
+	// 调整大小
+	window.Resize(fyne.NewSize(520, 600))
+
+	// 展示
+	window.Show()
+}
+
+func content(window fyne.Window, refreshCallback func()) fyne.CanvasObject {
+	title := widget.NewLabelWithStyle("扫描到的存档", fyne.TextAlignCenter, fyne.TextStyle{
+		Bold: true,
+	})
+
+	// 扫描结果 与勾选状态一一对应
+	var candidates []*world.Candidate
+	var selected []bool
+
+	statusLabel := widget.NewLabel("")
+	listBox := container.NewVBox()
+
+	var scan func()
+	scan = func() {
+		statusLabel.SetText("正在扫描...")
+		listBox.RemoveAll()
+		listBox.Add(widget.NewProgressBarInfinite())
+
+		go func() {
+			var result = archive.DiscoverWorlds()
+
+			fyne.Do(func() {
+				candidates = result
+				selected = make([]bool, len(result))
+				listBox.RemoveAll()
+
+				if len(result) == 0 {
+					statusLabel.SetText("没有找到尚未导入的存档")
+					return
+				}
+				statusLabel.SetText(fmt.Sprintf("找到 %d 个尚未导入的存档", len(result)))
+
+				for i, candidate := range result {
+					listBox.Add(candidateRow(candidate, func(checked bool) {
+						selected[i] = checked
+					}))
+				}
+			})
+		}()
+	}
+
+	// 用户添加的扫描目录
+	rootsBox := container.NewVBox()
+	refreshRoots(window, rootsBox, scan)
+
+	rootEntry := widget.NewEntry()
+	rootEntry.SetPlaceHolder("添加扫描目录，例如便携版启动器所在的文件夹")
+	addRootBtn := widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() {
+		if err := archive.AddDiscoverRoot(rootEntry.Text); err != nil {
+			dialog.NewInformation("添加失败", err.Error(), window).Show()
+			return
+		}
+		rootEntry.SetText("")
+		refreshRoots(window, rootsBox, scan)
+		scan()
+	})
+
+	scanBtn := widget.NewButtonWithIcon("重新扫描", theme.ViewRefreshIcon(), scan)
+
+	// 取消按钮
+	cancelBtn := widget.NewButtonWithIcon("取消", theme.CancelIcon(), func() {
+		manage.PutWindow(window)
+	})
+
+	// 导入按钮
+	importBtn := widget.NewButtonWithIcon("导入所选", theme.ConfirmIcon(), func() {
+		var chosen []*world.Candidate
+		for i, candidate := range candidates {
+			if selected[i] {
+				chosen = append(chosen, candidate)
+			}
+		}
+		if len(chosen) == 0 {
+			dialog.NewInformation("注意！", "请先勾选需要导入的存档", window).Show()
+			return
+		}
+
+		created, err := archive.ImportWorlds(chosen)
+		if len(created) > 0 && refreshCallback != nil {
+			refreshCallback()
+		}
+		if err != nil {
+			dialog.NewInformation("导入失败", fmt.Sprintf("已导入 %d 个存档\n%s", len(created), err.Error()), window).Show()
+			scan()
+			return
+		}
+
+		// 将窗口放回对象池
+		manage.PutWindow(window)
+	})
+	importBtn.Importance = widget.HighImportance
+
+	scan()
+
+	top := container.NewVBox(
+		container.NewPadded(title),
+		widget.NewLabel("扫描目录"),
+		rootsBox,
+		container.NewBorder(nil, nil, nil, addRootBtn, rootEntry),
+		container.NewBorder(nil, nil, nil, scanBtn, statusLabel),
+		widget.NewSeparator(),
+	)
+
+	bottom := container.NewPadded(container.NewHBox(
+		layout.NewSpacer(),
+		cancelBtn,
+		layout.NewSpacer(),
+		importBtn,
+		layout.NewSpacer(),
+	))
+
+	return container.NewPadded(container.NewBorder(top, bottom, nil, nil, container.NewVScroll(listBox)))
+}
+
+// candidateRow 单个存档的勾选行
+func candidateRow(candidate *world.Candidate, onChanged func(bool)) fyne.CanvasObject {
+	check := widget.NewCheck(candidate.Name, onChanged)
+
+	var lastPlayed = "未知"
+	if !candidate.LastPlayed.IsZero() {
+		lastPlayed = candidate.LastPlayed.Format("2006-01-02 15:04")
+	}
+	detail := widget.NewLabel(fmt.Sprintf("%s · %.2f MB · 最后游玩 %s\n%s",
+		candidate.Source, float64(candidate.Size)/1048576, lastPlayed, candidate.Path))
+	detail.Wrapping = fyne.TextWrapBreak
+	detail.SizeName = theme.SizeNameCaptionText
+
+	return container.NewVBox(check, container.NewPadded(detail), widget.NewSeparator())
+}
+
+// refreshRoots 展示用户添加的扫描目录 常见启动器的默认目录会自动扫描 不在此列出
+func refreshRoots(window fyne.Window, box *fyne.Container, onChanged func()) {
+	box.RemoveAll()
+
+	var roots = archive.DiscoverRoots()
+	if len(roots) == 0 {
+		box.Add(widget.NewLabel("已自动扫描 .minecraft 与常见启动器的默认目录"))
+	}
+
+	for _, root := range roots {
+		removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			if err := archive.RemoveDiscoverRoot(root); err != nil {
+				dialog.NewInformation("移除失败", err.Error(), window).Show()
+				return
+			}
+			refreshRoots(window, box, onChanged)
+			onChanged()
+		})
+		box.Add(container.NewBorder(nil, nil, nil, removeBtn, widget.NewLabel(root)))
+	}
+
+	box.Refresh()
+}
@@ -0,0 +1,166 @@
+package item_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/layout/manage"
+	"minecraft-archive-backup/model/dto/database"
+	"sort"
+	"strings"
+)
+
+// NewWindow 物品搜索窗口 在一段时间内的快照中查找存放指定物品的箱子与玩家
+func NewWindow(a *database.Archive) {
+	var window = manage.GetWindow()
+
+	// 标题
+	window.SetTitle(fmt.Sprintf("[ %s ] 物品搜索", a.Name))
+
+	// 内容
+	window.SetContent(content(a, window))
+
+	// 调整大小
+	window.Resize(fyne.NewSize(520, 620))
+
+	// 展示
+	window.Show()
+}
+
+func content(a *database.Archive, window fyne.Window) fyne.CanvasObject {
+	records, err := archive.GetBackupRecordsByArchiveID(a.ID)
+	if err != nil || len(records) == 0 {
+		var message = "还没有可以搜索的快照"
+		if err != nil {
+			message = err.Error()
+		}
+		return container.NewCenter(widget.NewLabel(message))
+	}
+
+	// 按时间排序 方便选择范围
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	var options = make([]string, len(records))
+	for i, record := range records {
+		options[i] = record.CreatedAt.Format("2006-01-02 15:04:05")
+		if record.Comment != "" {
+			options[i] += " " + record.Comment
+		}
+	}
+
+	itemEntry := widget.NewEntry()
+	itemEntry.SetPlaceHolder("物品 ID，例如 netherite_ingot")
+
+	fromSelect := widget.NewSelect(options, nil)
+	toSelect := widget.NewSelect(options, nil)
+	fromSelect.SetSelectedIndex(max(0, len(options)-5))
+	toSelect.SetSelectedIndex(len(options) - 1)
+
+	progress := widget.NewProgressBar()
+	progress.Hide()
+	results := container.NewVBox()
+
+	var searchBtn *widget.Button
+	searchBtn = widget.NewButtonWithIcon("搜索", theme.SearchIcon(), func() {
+		var itemID = strings.TrimSpace(itemEntry.Text)
+		if itemID == "" {
+			dialog.NewInformation("注意！", "请输入物品 ID", window).Show()
+			return
+		}
+		var from, to = fromSelect.SelectedIndex(), toSelect.SelectedIndex()
+		if from < 0 || to < 0 {
+			dialog.NewInformation("注意！", "请选择快照范围", window).Show()
+			return
+		}
+		if from > to {
+			from, to = to, from
+		}
+
+		var selected = append([]database.BackupRecord(nil), records[from:to+1]...)
+		searchBtn.Disable()
+		progress.SetValue(0)
+		progress.Show()
+		results.RemoveAll()
+
+		go func() {
+			var found = archive.SearchItems(a, selected, itemID, func(done, total int) {
+				fyne.Do(func() {
+					progress.SetValue(float64(done) / float64(total))
+				})
+			})
+			fyne.Do(func() {
+				searchBtn.Enable()
+				progress.Hide()
+				showResults(results, found)
+			})
+		}()
+	})
+	searchBtn.Importance = widget.HighImportance
+
+	tip := widget.NewLabel("在所选快照的箱子、木桶、潜影盒等容器与玩家背包中查找物品，潜影盒与收纳袋中的物品也会统计。")
+	tip.Wrapping = fyne.TextWrapWord
+
+	top := container.NewVBox(
+		tip,
+		widget.NewForm(
+			widget.NewFormItem("物品", itemEntry),
+			widget.NewFormItem("从", fromSelect),
+			widget.NewFormItem("到", toSelect),
+		),
+		container.NewCenter(searchBtn),
+		progress,
+		widget.NewSeparator(),
+	)
+
+	return container.NewBorder(container.NewPadded(top), nil, nil, nil,
+		container.NewVScroll(container.NewPadded(results)))
+}
+
+// showResults 每个快照一项 展开后显示物品所在的位置
+func showResults(box *fyne.Container, results []archive.ItemSearchResult) {
+	var accordion = widget.NewAccordion()
+	for _, result := range results {
+		var title = result.Record.CreatedAt.Format("2006-01-02 15:04:05")
+		var detail string
+		switch {
+		case result.Err != nil:
+			title += "  读取失败"
+			detail = result.Err.Error()
+		case len(result.Locations) == 0:
+			title += "  未找到"
+			detail = "该快照中没有这个物品"
+		default:
+			title += fmt.Sprintf("  共 %d 个 (%d 处)", result.Total, len(result.Locations))
+			var lines = make([]string, len(result.Locations))
+			for i, location := range result.Locations {
+				lines[i] = locationText(location)
+			}
+			detail = strings.Join(lines, "\n")
+		}
+
+		label := widget.NewLabel(detail)
+		label.Wrapping = fyne.TextWrapWord
+		accordion.Append(widget.NewAccordionItem(title, label))
+	}
+	box.Add(accordion)
+}
+
+// locationText 物品位置的一行文字
+func locationText(location archive.ItemLocation) string {
+	var holder = strings.TrimPrefix(location.Holder, "minecraft:")
+	if location.Player {
+		holder = "玩家 " + location.Holder
+		if location.Ender {
+			holder += " 的末影箱"
+		} else {
+			holder += " 的背包"
+		}
+	}
+	return fmt.Sprintf("%d 个  %s  %s (%d, %d, %d)",
+		location.Count, holder, location.Dimension, location.X, location.Y, location.Z)
+}
@@ -0,0 +1,168 @@
+package map_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/layout/manage"
+	"minecraft-archive-backup/model/dto/database"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 预览的中心
+const (
+	centerSpawn  = "出生点"
+	centerCustom = "指定坐标"
+)
+
+// 可选的预览半径 (区块)
+var radiusOptions = []string{"8", "16", "32", "64"}
+
+// NewWindow 快照的俯视地图预览 可以切换快照对比同一区域的变化
+// onRendered 在渲染出新的预览图后调用 用于刷新历史记录中的缩略图
+func NewWindow(a *database.Archive, record *database.BackupRecord, onRendered func()) {
+	var window = manage.GetWindow()
+
+	// 标题
+	window.SetTitle(fmt.Sprintf("[ %s ] 地图预览", a.Name))
+
+	// 内容
+	window.SetContent(content(a, record, window, onRendered))
+
+	// 调整大小
+	window.Resize(fyne.NewSize(640, 720))
+
+	// 展示
+	window.Show()
+}
+
+func content(a *database.Archive, current *database.BackupRecord, window fyne.Window, onRendered func()) fyne.CanvasObject {
+	records, err := archive.GetBackupRecordsByArchiveID(a.ID)
+	if err != nil {
+		return container.NewCenter(widget.NewLabel(err.Error()))
+	}
+
+	// 最新的快照在最前面
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+	var options = make([]string, len(records))
+	var selected int
+	for i, record := range records {
+		options[i] = record.CreatedAt.Format("2006-01-02 15:04:05")
+		if record.Comment != "" {
+			options[i] += " " + record.Comment
+		}
+		if record.ID == current.ID {
+			selected = i
+		}
+	}
+	snapshotSelect := widget.NewSelect(options, nil)
+
+	// 预览范围
+	xEntry, zEntry := widget.NewEntry(), widget.NewEntry()
+	xEntry.SetPlaceHolder("X")
+	zEntry.SetPlaceHolder("Z")
+	coordBox := container.NewGridWithColumns(2, xEntry, zEntry)
+	coordBox.Hide()
+
+	centerRadio := widget.NewRadioGroup([]string{centerSpawn, centerCustom}, func(center string) {
+		if center == centerCustom {
+			coordBox.Show()
+		} else {
+			coordBox.Hide()
+		}
+	})
+	centerRadio.Horizontal = true
+	centerRadio.SetSelected(centerSpawn)
+
+	radiusSelect := widget.NewSelect(radiusOptions, nil)
+	radiusSelect.SetSelected(strconv.Itoa(archive.DefaultPreviewRadius))
+
+	// 预览图 每个像素对应一个方块
+	var image = canvas.NewImageFromResource(nil)
+	image.FillMode = canvas.ImageFillOriginal
+	image.ScaleMode = canvas.ImageScalePixels
+	statusLabel := widget.NewLabel("")
+	statusLabel.Alignment = fyne.TextAlignCenter
+
+	var area = func() (archive.MapArea, error) {
+		radius, _ := strconv.Atoi(radiusSelect.Selected)
+		var area = archive.MapArea{Spawn: centerRadio.Selected != centerCustom, Radius: radius}
+		if area.Spawn {
+			return area, nil
+		}
+
+		x, errX := strconv.Atoi(strings.TrimSpace(xEntry.Text))
+		z, errZ := strconv.Atoi(strings.TrimSpace(zEntry.Text))
+		if errX != nil || errZ != nil {
+			return area, fmt.Errorf("方块坐标必须是整数")
+		}
+		area.X, area.Z = x, z
+		return area, nil
+	}
+
+	var renderBtn *widget.Button
+	var render = func() {
+		var index = snapshotSelect.SelectedIndex()
+		if index < 0 {
+			return
+		}
+		area, err := area()
+		if err != nil {
+			dialog.NewInformation("注意！", err.Error(), window).Show()
+			return
+		}
+
+		var record = records[index]
+		renderBtn.Disable()
+		statusLabel.SetText("正在从快照中读取区域文件...")
+
+		go func() {
+			p, err := archive.RenderMapPreview(a, &record, area)
+			fyne.Do(func() {
+				renderBtn.Enable()
+				if err != nil {
+					statusLabel.SetText("渲染失败")
+					dialog.NewInformation("渲染失败", err.Error(), window).Show()
+					return
+				}
+				statusLabel.SetText(fmt.Sprintf("%s 北方朝上，每个像素为一个方块", options[index]))
+				image.File = p
+				image.Refresh()
+				if onRendered != nil {
+					onRendered()
+				}
+			})
+		}()
+	}
+	renderBtn = widget.NewButtonWithIcon("渲染", theme.MediaPhotoIcon(), render)
+	renderBtn.Importance = widget.HighImportance
+
+	// 切换快照时保持范围不变 方便对比
+	snapshotSelect.OnChanged = func(string) {
+		render()
+	}
+	snapshotSelect.SetSelectedIndex(selected)
+
+	top := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("快照", snapshotSelect),
+			widget.NewFormItem("中心", centerRadio),
+			widget.NewFormItem("半径 (区块)", radiusSelect),
+		),
+		coordBox,
+		container.NewCenter(renderBtn),
+		statusLabel,
+	)
+
+	return container.NewBorder(container.NewPadded(top), nil, nil, nil,
+		container.NewScroll(container.NewCenter(image)))
+}
@@ -0,0 +1,222 @@
+package player_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/layout/manage"
+	"minecraft-archive-backup/model/dto/database"
+	"sort"
+	"sync"
+)
+
+// NewWindow 玩家回档窗口 只将单个玩家的数据恢复到快照中的状态
+// onFinished 回档完成后调用 用于刷新历史记录 (回档前会创建安全备份)
+func NewWindow(a *database.Archive, onFinished func()) {
+	var window = manage.GetWindow()
+
+	// 标题
+	window.SetTitle(fmt.Sprintf("[ %s ] 玩家回档", a.Name))
+
+	// 内容
+	window.SetContent(content(a, window, onFinished))
+
+	// 调整大小
+	window.Resize(fyne.NewSize(480, 580))
+
+	// 展示
+	window.Show()
+}
+
+func content(a *database.Archive, window fyne.Window, onFinished func()) fyne.CanvasObject {
+	players, err := archive.ListPlayers(a)
+	if err != nil {
+		return container.NewCenter(widget.NewLabel(err.Error()))
+	}
+	if len(players) == 0 {
+		return container.NewCenter(widget.NewLabel("存档中没有玩家数据"))
+	}
+
+	records, err := archive.GetBackupRecordsByArchiveID(a.ID)
+	if err != nil || len(records) == 0 {
+		var message = "还没有可用于回档的快照"
+		if err != nil {
+			message = err.Error()
+		}
+		return container.NewCenter(widget.NewLabel(message))
+	}
+
+	// 最新的快照在最前面
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+
+	// 每个快照中玩家的概要 切换玩家时清空 在后台依次读取
+	var (
+		mu        sync.Mutex
+		summaries = make(map[int]string)
+		loadID    int
+		selected  = -1
+	)
+
+	snapshotList := widget.NewList(
+		func() int { return len(records) },
+		func() fyne.CanvasObject {
+			summary := widget.NewLabel("")
+			summary.SizeName = theme.SizeNameCaptionText
+			return container.NewVBox(widget.NewLabel(""), summary)
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			var box = object.(*fyne.Container)
+			box.Objects[0].(*widget.Label).SetText(recordLabel(&records[id]))
+
+			mu.Lock()
+			summary, ok := summaries[id]
+			mu.Unlock()
+			if !ok {
+				summary = "读取中..."
+			}
+			box.Objects[1].(*widget.Label).SetText(summary)
+		},
+	)
+	snapshotList.OnSelected = func(id widget.ListItemID) {
+		selected = id
+	}
+
+	var playerNames = make([]string, len(players))
+	for i, player := range players {
+		playerNames[i] = player.DisplayName()
+	}
+	playerSelect := widget.NewSelect(playerNames, nil)
+	playerSelect.OnChanged = func(string) {
+		var player = players[playerSelect.SelectedIndex()]
+
+		mu.Lock()
+		loadID++
+		var id = loadID
+		summaries = make(map[int]string)
+		mu.Unlock()
+		snapshotList.Refresh()
+
+		go func() {
+			for i := range records {
+				summary, err := archive.SnapshotPlayer(a, &records[i], player.UUID)
+
+				var text string
+				switch {
+				case err != nil:
+					text = "读取失败: " + err.Error()
+				case summary == nil:
+					text = "快照中没有该玩家"
+				default:
+					text = summaryText(summary)
+				}
+
+				mu.Lock()
+				if id != loadID {
+					// 已切换到其他玩家
+					mu.Unlock()
+					return
+				}
+				summaries[i] = text
+				mu.Unlock()
+				fyne.Do(func() {
+					snapshotList.RefreshItem(i)
+				})
+			}
+		}()
+	}
+
+	statsCheck := widget.NewCheck("同时恢复统计信息 (stats)", nil)
+	advancementsCheck := widget.NewCheck("同时恢复进度 (advancements)", nil)
+
+	restoreBtn := widget.NewButtonWithIcon("恢复该玩家", theme.HistoryIcon(), func() {
+		var playerIndex = playerSelect.SelectedIndex()
+		if playerIndex < 0 {
+			dialog.NewInformation("注意！", "请选择玩家", window).Show()
+			return
+		}
+		if selected < 0 {
+			dialog.NewInformation("注意！", "请选择快照", window).Show()
+			return
+		}
+
+		var player = players[playerIndex]
+		var record = records[selected]
+
+		manage.ShowConfirmInputDialog(&manage.ConfirmInputConfig{
+			Title: "玩家回档",
+			Message: fmt.Sprintf("将玩家 %s 的数据恢复到 %s\n回档前会自动创建安全备份",
+				player.DisplayName(), record.CreatedAt.Format("2006年01月02日15:04:05")),
+			ExpectedInput: "确认回档",
+			Placeholder:   "请输入确认回档",
+			ErrorTest:     "该玩家之后的背包、位置等改动都会丢失",
+			Parent:        window,
+			Size:          fyne.Size{Width: 300, Height: 260},
+			Callback: func(input string, confirmed bool) {
+				if !confirmed {
+					return
+				}
+
+				var progress = dialog.NewCustomWithoutButtons("正在恢复玩家数据", widget.NewProgressBarInfinite(), window)
+				progress.Show()
+
+				go func() {
+					err := archive.RestorePlayer(a, &record, player.UUID, statsCheck.Checked, advancementsCheck.Checked)
+					fyne.Do(func() {
+						progress.Hide()
+						if onFinished != nil {
+							onFinished()
+						}
+						if err != nil {
+							dialog.NewInformation("玩家回档失败", err.Error(), window).Show()
+							return
+						}
+						dialog.NewInformation("玩家回档成功", fmt.Sprintf("已恢复玩家 %s 的数据", player.DisplayName()), window).Show()
+					})
+				}()
+			},
+		})
+	})
+	restoreBtn.Importance = widget.HighImportance
+
+	tip := widget.NewLabel("只恢复所选玩家的背包、位置等数据，其他玩家与世界保持不变。请先退出存档或关闭服务器。")
+	tip.Wrapping = fyne.TextWrapWord
+
+	top := container.NewVBox(
+		tip,
+		widget.NewForm(widget.NewFormItem("玩家", playerSelect)),
+		widget.NewLabel("选择快照:"),
+	)
+	bottom := container.NewVBox(
+		widget.NewSeparator(),
+		statsCheck,
+		advancementsCheck,
+		container.NewCenter(restoreBtn),
+	)
+
+	playerSelect.SetSelectedIndex(0)
+
+	return container.NewPadded(container.NewBorder(top, bottom, nil, nil, snapshotList))
+}
+
+// summaryText 玩家概要的一行文字
+func summaryText(summary *world.PlayerSummary) string {
+	return fmt.Sprintf("%s (%.0f, %.0f, %.0f) | 生命 %.1f | 等级 %d | 物品 %d",
+		world.DimensionIDName(summary.Dimension), summary.X, summary.Y, summary.Z,
+		summary.Health, summary.XPLevel, summary.ItemCount)
+}
+
+// recordLabel 快照的显示名称
+func recordLabel(record *database.BackupRecord) string {
+	var label = record.CreatedAt.Format("2006-01-02 15:04:05")
+	if record.Comment != "" {
+		label += " " + record.Comment
+	}
+	return label
+}
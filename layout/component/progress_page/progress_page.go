@@ -18,6 +18,11 @@ type Mode int
 const (
 	ModeBackup  Mode = iota // 备份模式
 	ModeRestore Mode = 1    // 回档模式
+	ModeMigrate Mode = 2    // 迁移仓库模式
+	ModeChunks  Mode = 3    // 区块回档模式
+	ModePrune   Mode = 4    // 清理区块模式
+	ModeExport  Mode = 5    // 导出快照模式
+	ModeImport  Mode = 6    // 导入备份模式
 )
 
 // CompletionCallback 回调函数类型
@@ -31,6 +36,16 @@ func getTitleByMode(mode Mode) string {
 		return "正在备份中"
 	case ModeRestore:
 		return "正在回档中"
+	case ModeMigrate:
+		return "正在迁移仓库"
+	case ModeChunks:
+		return "正在回档区块"
+	case ModePrune:
+		return "正在清理区块"
+	case ModeExport:
+		return "正在导出快照"
+	case ModeImport:
+		return "正在导入备份"
 	}
 	return ""
 }
@@ -238,7 +253,11 @@ func (pw *ProgressWindow) updateUI(msg *archive.BackupMessage) {
 			}
 
 		case "summary":
-			statusParts = append(statusParts, "正在创建快照...")
+			if msg.Skipped() {
+				statusParts = append(statusParts, "存档没有变化，已跳过")
+			} else {
+				statusParts = append(statusParts, "正在创建快照...")
+			}
 			if msg.TotalDuration > 0 {
 				statusParts = append(statusParts,
 					fmt.Sprintf("耗时: %.1fs", msg.TotalDuration))
@@ -339,6 +358,9 @@ func (pw *ProgressWindow) complete(success bool, errorMsg string, lastMsg *archi
 				if pw.lastMessage.SnapshotID != "" {
 					details = append(details, fmt.Sprintf("快照ID: %s", pw.lastMessage.SnapshotID))
 				}
+				if pw.lastMessage.Skipped() {
+					details = append(details, "存档没有变化，未创建新的快照")
+				}
 				if pw.lastMessage.Message != "" {
 					details = append(details, fmt.Sprintf("消息: %s", pw.lastMessage.Message))
 				}
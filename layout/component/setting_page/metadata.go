@@ -0,0 +1,121 @@
+package setting_page
+
+import (
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/layout/manage"
+	task "minecraft-archive-backup/pkg/task/core"
+	"strings"
+)
+
+// metadataContent 程序数据(存档列表与配置)的备份与恢复
+// onRestored 在恢复成功后调用
+func metadataContent(window fyne.Window, onRestored func()) fyne.CanvasObject {
+	tip := widget.NewLabel("程序数据包含存档列表、备份记录与配置文件，每小时自动备份到仓库中")
+	tip.Wrapping = fyne.TextWrapWord
+
+	backupBtn := widget.NewButtonWithIcon("立即备份", theme.UploadIcon(), func() {
+		var progress = dialog.NewCustomWithoutButtons("正在备份程序数据", widget.NewProgressBarInfinite(), window)
+		progress.Show()
+
+		go func() {
+			summary, err := archive.BackupMetadata()
+			fyne.Do(func() {
+				progress.Hide()
+				switch {
+				case errors.Is(err, archive.ErrNoArchives):
+					dialog.NewInformation("无需备份", err.Error(), window).Show()
+				case err != nil:
+					dialog.NewInformation("备份失败", err.Error(), window).Show()
+				case summary.Skipped():
+					dialog.NewInformation("备份完成", "程序数据没有变化，无需备份", window).Show()
+				default:
+					dialog.NewInformation("备份完成", fmt.Sprintf("快照ID: %s", summary.SnapshotID[:8]), window).Show()
+				}
+			})
+		}()
+	})
+
+	restoreBtn := widget.NewButtonWithIcon("从仓库恢复", theme.DownloadIcon(), func() {
+		showRestoreMetadataDialog(window, onRestored)
+	})
+	restoreBtn.Importance = widget.WarningImportance
+
+	return container.NewVBox(
+		widget.NewLabel("程序数据"),
+		tip,
+		container.NewGridWithColumns(2, backupBtn, restoreBtn),
+	)
+}
+
+// showRestoreMetadataDialog 输入仓库路径与密码 恢复最近一次备份的程序数据
+func showRestoreMetadataDialog(window fyne.Window, onRestored func()) {
+	repoEntry := widget.NewEntry()
+	repoEntry.SetText(archive.ResticRepoPath())
+
+	passwordEntry := widget.NewPasswordEntry()
+	passwordEntry.SetText(archive.ResticPassword())
+
+	var dlg = dialog.NewForm("从仓库恢复程序数据", "下一步", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("仓库路径", repoEntry),
+			widget.NewFormItem("仓库密码", passwordEntry),
+		},
+		func(confirm bool) {
+			if !confirm {
+				return
+			}
+
+			var repoPath = strings.TrimSpace(repoEntry.Text)
+			var password = passwordEntry.Text
+
+			manage.ShowConfirmInputDialog(&manage.ConfirmInputConfig{
+				Title:         "恢复程序数据",
+				Message:       "当前的存档列表与配置将被仓库中的备份覆盖",
+				ExpectedInput: "确认恢复",
+				Placeholder:   "请输入确认恢复",
+				ErrorTest:     "恢复后当前未备份的存档信息将会丢失！",
+				Parent:        window,
+				Size:          fyne.Size{Width: 250, Height: 250},
+				Callback: func(input string, confirmed bool) {
+					if !confirmed {
+						return
+					}
+
+					var progress = dialog.NewCustomWithoutButtons("正在恢复程序数据", widget.NewProgressBarInfinite(), window)
+					progress.Show()
+
+					go func() {
+						// 定时任务会读写数据库与仓库 恢复前先停止 恢复后重启程序才会重新启动
+						task.TaskMenger.Stop()
+						archive.StopWatchGameVersions()
+
+						err := archive.RestoreMetadata(repoPath, password)
+						fyne.Do(func() {
+							progress.Hide()
+							if err != nil {
+								dialog.NewInformation("恢复失败", err.Error()+"\n请重启程序", window).Show()
+								return
+							}
+							onRestored()
+
+							// 恢复的数据库在下次启动时生效 关闭提示后退出程序
+							var info = dialog.NewInformation("恢复成功", "存档列表与配置已恢复，程序将退出，请重新启动", window)
+							info.SetOnClosed(fyne.CurrentApp().Quit)
+							info.Show()
+						})
+					}()
+				},
+			})
+		},
+		window,
+	)
+	dlg.Resize(fyne.NewSize(380, 200))
+	dlg.Show()
+}
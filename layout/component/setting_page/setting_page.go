@@ -0,0 +1,168 @@
+package setting_page
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/archive"
+	"minecraft-archive-backup/layout/component/progress_page"
+	"minecraft-archive-backup/layout/manage"
+	"strings"
+)
+
+// 迁移方式
+const (
+	migrateCopy = "复制 (保留旧仓库作为副本)"
+	migrateMove = "移动 (确认后删除旧仓库)"
+)
+
+// NewWindow 仓库设置窗口
+// refreshCallback 恢复程序数据后 用于刷新首页的存档列表
+func NewWindow(refreshCallback func()) {
+	var window = manage.GetWindow()
+
+	// 标题
+	window.SetTitle("仓库设置")
+
+	// 内容
+	window.SetContent(content(window, refreshCallback))
+
+	// 调整大小
+	window.Resize(fyne.NewSize(420, 480))
+
+	// 展示
+	window.Show()
+}
+
+func content(window fyne.Window, refreshCallback func()) fyne.CanvasObject {
+	title := widget.NewLabelWithStyle("备份仓库", fyne.TextAlignCenter, fyne.TextStyle{
+		Bold: true,
+	})
+
+	// 当前仓库路径
+	currentLabel := widget.NewLabel(archive.ResticRepoPath())
+	currentLabel.Wrapping = fyne.TextWrapBreak
+
+	// 新的仓库路径
+	targetEntry := widget.NewEntry()
+	targetEntry.SetPlaceHolder("输入新的仓库文件夹路径 (需为空文件夹)")
+
+	// 迁移方式
+	modeRadio := widget.NewRadioGroup([]string{migrateCopy, migrateMove}, nil)
+	modeRadio.SetSelected(migrateMove)
+
+	// 等待确认删除的旧仓库
+	oldRepoBox := container.NewVBox()
+	refreshOldRepo(window, oldRepoBox)
+
+	migrateBtn := widget.NewButtonWithIcon("开始迁移", theme.ConfirmIcon(), func() {
+		var target = strings.TrimSpace(targetEntry.Text)
+		if target == "" {
+			dialog.NewInformation("注意！", "新的仓库路径为空", window).Show()
+			return
+		}
+		if archive.PendingOldRepository() != "" {
+			dialog.NewInformation("注意！", "请先处理上一次迁移留下的旧仓库", window).Show()
+			return
+		}
+
+		var move = modeRadio.Selected == migrateMove
+
+		manage.ShowConfirmInputDialog(&manage.ConfirmInputConfig{
+			Title:         "迁移仓库",
+			Message:       "迁移期间请不要进行备份或回档",
+			ExpectedInput: "确认迁移",
+			Placeholder:   "请输入确认迁移",
+			ErrorTest:     "校验通过后才会切换到新仓库",
+			Parent:        window,
+			Size:          fyne.Size{Width: 250, Height: 250},
+			Callback: func(input string, confirmed bool) {
+				if !confirmed {
+					return
+				}
+
+				var stdChan = archive.MigrateRepository(target, move)
+				progress_page.NewWindow(nil, progress_page.ModeMigrate, stdChan, func(success bool, errorMsg string, lastMessage *archive.BackupMessage) {
+					fyne.Do(func() {
+						if !success {
+							dialog.NewInformation("迁移失败", errorMsg, window).Show()
+							return
+						}
+
+						currentLabel.SetText(archive.ResticRepoPath())
+						refreshOldRepo(window, oldRepoBox)
+						dialog.NewInformation("迁移成功", "新仓库已通过校验并开始使用", window).Show()
+					})
+				})
+			},
+		})
+	})
+	migrateBtn.Importance = widget.HighImportance
+
+	mainContainer := container.NewVBox(
+		container.NewPadded(title),
+		widget.NewLabel("当前仓库"),
+		currentLabel,
+		widget.NewSeparator(),
+		widget.NewLabel("迁移到"),
+		targetEntry,
+		modeRadio,
+		container.NewHBox(layout.NewSpacer(), migrateBtn, layout.NewSpacer()),
+		oldRepoBox,
+		widget.NewSeparator(),
+		metadataContent(window, func() {
+			// 恢复的程序数据可能切换了仓库
+			currentLabel.SetText(archive.ResticRepoPath())
+			refreshOldRepo(window, oldRepoBox)
+			if refreshCallback != nil {
+				refreshCallback()
+			}
+		}),
+	)
+
+	return container.NewPadded(mainContainer)
+}
+
+// refreshOldRepo 展示等待确认删除的旧仓库
+func refreshOldRepo(window fyne.Window, box *fyne.Container) {
+	box.RemoveAll()
+
+	var oldRepo = archive.PendingOldRepository()
+	if oldRepo == "" {
+		box.Refresh()
+		return
+	}
+
+	oldLabel := widget.NewLabel(fmt.Sprintf("旧仓库仍保留在: %s", oldRepo))
+	oldLabel.Wrapping = fyne.TextWrapBreak
+
+	deleteBtn := widget.NewButtonWithIcon("确认新仓库可用，删除旧仓库", theme.DeleteIcon(), func() {
+		manage.ShowConfirmInputDialog(&manage.ConfirmInputConfig{
+			Title:         "删除旧仓库",
+			Message:       "请确认新仓库可以正常备份与回档",
+			ExpectedInput: "删除旧仓库",
+			Parent:        window,
+			Size:          fyne.Size{Width: 250, Height: 250},
+			Callback: func(input string, confirmed bool) {
+				if !confirmed {
+					return
+				}
+				if err := archive.ConfirmRepositoryMigration(); err != nil {
+					dialog.NewInformation("删除旧仓库失败", err.Error(), window).Show()
+					return
+				}
+				refreshOldRepo(window, box)
+			},
+		})
+	})
+	deleteBtn.Importance = widget.DangerImportance
+
+	box.Add(widget.NewSeparator())
+	box.Add(oldLabel)
+	box.Add(deleteBtn)
+	box.Refresh()
+}
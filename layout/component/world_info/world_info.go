@@ -0,0 +1,98 @@
+package world_info
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"minecraft-archive-backup/internal/world"
+	"minecraft-archive-backup/layout/resource/icon"
+	"minecraft-archive-backup/model/dto/database"
+	"strconv"
+)
+
+// NewIcon 存档图标 没有 icon.png 时使用默认图标
+func NewIcon(info *database.WorldInfo, size float32) fyne.CanvasObject {
+	var resource fyne.Resource = icon.MinecraftPng
+	if info != nil && len(info.Icon) > 0 {
+		// 基岩版的图标为 jpeg 图片格式由内容识别
+		resource = fyne.NewStaticResource(fmt.Sprintf("world-%d-icon", info.ArchiveID), info.Icon)
+	}
+
+	image := canvas.NewImageFromResource(resource)
+	image.FillMode = canvas.ImageFillContain
+	// 存档图标只有 64x64 放大时保持像素风格
+	image.ScaleMode = canvas.ImageScalePixels
+	image.SetMinSize(fyne.NewSize(size, size))
+	return image
+}
+
+// Summary 卡片上展示的简要信息
+func Summary(info *database.WorldInfo) string {
+	if info == nil {
+		return "未读取到 level.dat"
+	}
+	return fmt.Sprintf("%s · %s · %s · 第 %d 天",
+		versionName(info),
+		world.GameTypeName(info.GameType, info.Hardcore),
+		world.DifficultyName(info.Difficulty),
+		info.Days,
+	)
+}
+
+// LastPlayed 最后游玩时间
+func LastPlayed(info *database.WorldInfo) string {
+	return "最后游玩: " + lastPlayed(info)
+}
+
+// NewDetails 存档信息页面中展示的完整信息
+func NewDetails(info *database.WorldInfo) fyne.CanvasObject {
+	if info == nil {
+		return widget.NewLabel("未读取到 level.dat")
+	}
+
+	// 种子可以选中复制
+	seedEntry := widget.NewEntry()
+	seedEntry.SetText(strconv.FormatInt(info.Seed, 10))
+	seedEntry.Disable()
+
+	form := widget.NewForm(
+		widget.NewFormItem("世界名称", widget.NewLabel(info.LevelName)),
+		widget.NewFormItem("游戏版本", widget.NewLabel(versionDetail(info))),
+		widget.NewFormItem("游戏模式", widget.NewLabel(world.GameTypeName(info.GameType, info.Hardcore))),
+		widget.NewFormItem("难度", widget.NewLabel(world.DifficultyName(info.Difficulty))),
+		widget.NewFormItem("种子", seedEntry),
+		widget.NewFormItem("游戏天数", widget.NewLabel(fmt.Sprintf("第 %d 天", info.Days))),
+		widget.NewFormItem("最后游玩", widget.NewLabel(lastPlayed(info))),
+	)
+
+	return container.NewBorder(nil, nil, container.NewCenter(NewIcon(info, 64)), nil, form)
+}
+
+// versionName 旧版本的 level.dat 中没有版本名称
+func versionName(info *database.WorldInfo) string {
+	var name = info.VersionName
+	if name == "" {
+		name = "未知版本"
+	}
+	if info.Bedrock {
+		return "基岩版 " + name
+	}
+	return name
+}
+
+func lastPlayed(info *database.WorldInfo) string {
+	if info == nil || info.LastPlayed.IsZero() {
+		return "未知"
+	}
+	return info.LastPlayed.Format("2006-01-02 15:04")
+}
+
+// versionDetail 版本与数据版本号 基岩版记录的是网络协议版本
+func versionDetail(info *database.WorldInfo) string {
+	if info.Bedrock {
+		return fmt.Sprintf("%s (协议版本 %d)", versionName(info), info.DataVersion)
+	}
+	return fmt.Sprintf("%s (DataVersion %d)", versionName(info), info.DataVersion)
+}
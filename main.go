@@ -8,6 +8,7 @@ import (
 	"minecraft-archive-backup/internal/archive"
 	"minecraft-archive-backup/layout/component/home_page"
 	etc "minecraft-archive-backup/pkg/etc/core"
+	_ "minecraft-archive-backup/pkg/task/core" // 启动定时任务
 )
 
 func main() {
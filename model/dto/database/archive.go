@@ -4,12 +4,42 @@ import (
 	"time"
 )
 
+// ArchiveType 存档的类型
+type ArchiveType string
+
+const (
+	ArchiveTypeWorld    ArchiveType = "world"    // 单个世界
+	ArchiveTypeBedrock  ArchiveType = "bedrock"  // 基岩版世界
+	ArchiveTypeInstance ArchiveType = "instance" // 整个游戏实例 (模组、配置、资源包等)
+	ArchiveTypeServer   ArchiveType = "server"   // 服务器目录
+	ArchiveTypeFolder   ArchiveType = "folder"   // 任意文件夹
+)
+
 // Archive 存档的信息
 type Archive struct {
 	ID        uint `gorm:"primarykey"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Name      string `gorm:"unique;not null"` // 存档的名称 唯一
-	Comment   string // 存档的备注 可以为空
-	Path      string `gorm:"unique;not null"` // 存档的路径 唯一
+	Name      string      `gorm:"unique;not null"` // 存档的名称 唯一
+	Comment   string      // 存档的备注 可以为空
+	Type      ArchiveType `gorm:"default:world"`   // 存档的类型 旧数据默认为世界
+	Path      string      `gorm:"unique;not null"` // 存档的路径 唯一
+	Quota     int64       // 存档在仓库中允许占用的最大空间(字节) 0 表示不限制
+	Excludes  string      // 备份时的排除规则 每行一条
+	// NormalizeRegions 备份前在暂存的副本中规范化区域文件 提高去重率 只对世界与服务器生效
+	NormalizeRegions bool
+	// ScanBeforeBackup 备份前检查 level.dat、区域文件与玩家数据是否损坏
+	ScanBeforeBackup bool
+	// ExtraPaths 与主路径一起备份的附加路径
+	ExtraPaths []ArchivePath `gorm:"foreignKey:ArchiveID"`
+}
+
+// BackupPaths 需要备份的所有路径 主路径在第一位
+func (a *Archive) BackupPaths() []string {
+	var paths = make([]string, 0, len(a.ExtraPaths)+1)
+	paths = append(paths, a.Path)
+	for _, extra := range a.ExtraPaths {
+		paths = append(paths, extra.Path)
+	}
+	return paths
 }
@@ -0,0 +1,9 @@
+package database
+
+// ArchivePath 存档的附加路径
+// 例如服务器的 world_nether、world_the_end 与 plugins 文件夹 与主路径一起备份到同一个快照中
+type ArchivePath struct {
+	ID        uint   `gorm:"primarykey"`
+	ArchiveID uint   `gorm:"index"`
+	Path      string `gorm:"unique;not null"`
+}
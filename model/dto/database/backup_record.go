@@ -4,6 +4,16 @@ import (
 	"time"
 )
 
+// HealthStatus 备份前检查存档完整性的结果
+type HealthStatus string
+
+const (
+	HealthUnknown  HealthStatus = ""         // 没有检查
+	HealthHealthy  HealthStatus = "healthy"  // 没有发现问题
+	HealthWarnings HealthStatus = "warnings" // 只有不影响游戏的问题
+	HealthCorrupt  HealthStatus = "corrupt"  // 存在无法读取的数据
+)
+
 // BackupRecord 存档的历史备份记录
 type BackupRecord struct {
 	ID        uint `gorm:"primarykey"`
@@ -13,4 +23,18 @@ type BackupRecord struct {
 	Archive   Archive
 	SnapShot  string `gorm:"unique;size:64"`
 	Comment   string
+	Parent    string  `gorm:"size:64"` // 增量备份所基于的父快照 为空表示完整读取
+	DataAdded int64   // 本次备份新增的数据量(字节)
+	Duration  float64 // 本次备份耗时(秒)
+	Pinned    bool    // 是否已固定 固定的快照不会被删除或淘汰 同时在 restic 中带有 pinned 标签
+	// Normalized 备份前是否规范化了区域文件
+	Normalized bool
+	// Health 备份前检查存档完整性的结果 HealthReport 为发现的问题 每行一条
+	Health       HealthStatus
+	HealthReport string
+	// DataVersion 备份时 level.dat 中的数据版本 VersionName 为对应的游戏版本 没有世界的类型为空
+	DataVersion int
+	VersionName string
+	// Manifest 备份时的模组、数据包与加载器清单 (JSON) 原版存档为空
+	Manifest string
 }
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"time"
+)
+
+// QuotaEviction 因超出存档配额而被淘汰的快照记录
+type QuotaEviction struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	ArchiveID uint      `gorm:"index"`
+	SnapShot  string    `gorm:"size:64"`
+	Comment   string    // 被淘汰快照的备注
+	BackupAt  time.Time // 被淘汰快照的备份时间
+	DataAdded int64     // 被淘汰快照备份时新增的数据量(字节)
+	// 同一次备份前的淘汰是一批 Usage 与 UsageAfter 为整批淘汰前后存档占用的空间(字节)
+	Usage      int64
+	UsageAfter int64
+	Estimate   int64 // 新快照预计新增的数据量(字节)
+	Quota      int64 // 淘汰时的配额(字节)
+}
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"time"
+)
+
+// WorldInfo 从存档 level.dat 中读取的信息缓存
+// level.dat 的修改时间没有变化时 直接使用缓存 不再重复解析
+type WorldInfo struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ArchiveID    uint      `gorm:"uniqueIndex"`
+	LevelModTime time.Time // 解析时 level.dat 的修改时间
+	Bedrock      bool      // 基岩版存档
+	LevelName    string
+	VersionName  string
+	DataVersion  int
+	GameType     int
+	Hardcore     bool
+	Difficulty   int
+	Seed         int64
+	LastPlayed   time.Time
+	Days         int64
+	Icon         []byte // 存档图标 icon.png
+}
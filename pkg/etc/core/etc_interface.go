@@ -156,6 +156,45 @@ func (c *Config) LoadVipers(key string) (*SafeViper, error) {
 	return v, nil
 }
 
+// SaveAtomic 修改若干配置项 并以原子替换的方式写回配置文件
+// 先写入同目录下的临时文件 再重命名覆盖原文件 避免写入中途失败导致配置文件损坏
+func (s *SafeViper) SaveAtomic(values map[string]any) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	var (
+		filePath = s.V.ConfigFileUsed()
+		ext      = filepath.Ext(filePath)
+		tmpPath  = strings.TrimSuffix(filePath, ext) + ".tmp" + ext
+	)
+
+	// 记录旧值 写入失败时回滚内存中的配置
+	var oldValues = make(map[string]any, len(values))
+	for key, value := range values {
+		oldValues[key] = s.V.Get(key)
+		s.V.Set(key, value)
+	}
+
+	var rollback = func() {
+		for key, value := range oldValues {
+			s.V.Set(key, value)
+		}
+		_ = os.Remove(tmpPath)
+	}
+
+	if err := s.V.WriteConfigAs(tmpPath); err != nil {
+		rollback()
+		return fmt.Errorf("failed to write temp config: %v", err)
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		rollback()
+		return fmt.Errorf("failed to replace config file: %v", err)
+	}
+
+	return nil
+}
+
 // Keys 返回当前绑定的所有配置文件key
 func (c *Config) Keys() (result []string) {
 	c.lock.RLock()
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"github.com/fsnotify/fsnotify"
+	etc "minecraft-archive-backup/pkg/etc/core"
+	"path/filepath"
+)
+
+type Discover struct {
+	// Roots 用户添加的扫描目录 例如便携版启动器所在的文件夹
+	Roots []string
+}
+
+func (d *Discover) Key() string {
+	return "Discover"
+}
+
+func (d *Discover) FilePath() string {
+	return filepath.Join(etc.ConfigDir, "discover.yaml")
+}
+
+func (d *Discover) DefaultValueMap() map[string]any {
+	return map[string]any{
+		"roots": []string{},
+	}
+}
+
+func (d *Discover) WatchFun() func(fsnotify.Event) {
+	return func(event fsnotify.Event) {
+
+	}
+}
@@ -9,8 +9,17 @@ import (
 	"path/filepath"
 )
 
+// DefaultHost 默认的固定主机名
+const DefaultHost = "minecraft-archive-backup"
+
 type Restic struct {
 	Password string
+	// Repository 仓库所处路径 为空时使用 data/restic/repo
+	Repository string
+	// OldRepository 迁移后等待用户确认删除的旧仓库路径
+	OldRepository string
+	// Host 备份时使用的固定主机名 避免电脑改名后 restic 找不到父快照
+	Host string
 }
 
 func (r *Restic) Key() string {
@@ -23,7 +32,10 @@ func (r *Restic) FilePath() string {
 
 func (r *Restic) DefaultValueMap() map[string]any {
 	return map[string]any{
-		"password": GeneratePassword(),
+		"password":       GeneratePassword(),
+		"repository":     "",
+		"old_repository": "",
+		"host":           DefaultHost,
 	}
 }
 
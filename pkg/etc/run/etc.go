@@ -11,6 +11,7 @@ var (
 
 func init() {
 	_ = cfg.AddConfig(&model.Restic{})
+	_ = cfg.AddConfig(&model.Discover{})
 }
 
 // LoadVipers 加载某一个配置
@@ -0,0 +1,94 @@
+package nbt
+
+// Compound NBT 的 Compound 标签
+type Compound map[string]any
+
+// Compound 获取子 Compound 不存在或类型不符时返回 nil
+func (c Compound) Compound(key string) Compound {
+	value, _ := c[key].(Compound)
+	return value
+}
+
+// Path 按路径逐级获取子 Compound
+func (c Compound) Path(keys ...string) Compound {
+	var current = c
+	for _, key := range keys {
+		if current == nil {
+			return nil
+		}
+		current = current.Compound(key)
+	}
+	return current
+}
+
+// Has 判断是否存在指定的键
+func (c Compound) Has(key string) bool {
+	_, ok := c[key]
+	return ok
+}
+
+// String 获取字符串 不存在时返回空字符串
+func (c Compound) String(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+// Int 获取任意整数类型的值 不存在时返回 0
+func (c Compound) Int(key string) int64 {
+	return ToInt(c[key])
+}
+
+// Float 获取任意数值类型的值 不存在时返回 0
+func (c Compound) Float(key string) float64 {
+	switch v := c[key].(type) {
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return float64(ToInt(c[key]))
+}
+
+// Bool 获取以 Byte 存储的布尔值
+func (c Compound) Bool(key string) bool {
+	return c.Int(key) != 0
+}
+
+// List 获取列表 不存在时返回 nil
+func (c Compound) List(key string) []any {
+	value, _ := c[key].([]any)
+	return value
+}
+
+// CompoundList 获取元素为 Compound 的列表
+func (c Compound) CompoundList(key string) []Compound {
+	var list = c.List(key)
+	var result = make([]Compound, 0, len(list))
+	for _, item := range list {
+		if compound, ok := item.(Compound); ok {
+			result = append(result, compound)
+		}
+	}
+	return result
+}
+
+// LongArray 获取 LongArray 不存在时返回 nil
+func (c Compound) LongArray(key string) []int64 {
+	value, _ := c[key].([]int64)
+	return value
+}
+
+// ToInt 将任意整数类型转换为 int64
+func ToInt(value any) int64 {
+	switch v := value.(type) {
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	}
+	return 0
+}
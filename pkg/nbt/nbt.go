@@ -0,0 +1,277 @@
+package nbt
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// NBT 标签类型
+const (
+	TagEnd byte = iota
+	TagByte
+	TagShort
+	TagInt
+	TagLong
+	TagFloat
+	TagDouble
+	TagByteArray
+	TagString
+	TagList
+	TagCompound
+	TagIntArray
+	TagLongArray
+)
+
+// 防止损坏的数据导致超大内存分配或无限递归
+const (
+	maxArrayLen = 1 << 24
+	maxDepth    = 512
+)
+
+var (
+	ErrInvalidTag   = errors.New("nbt: 无效的标签类型")
+	ErrTooLarge     = errors.New("nbt: 数组长度超出限制")
+	ErrTooDeep      = errors.New("nbt: 嵌套层级过深")
+	ErrNotCompound  = errors.New("nbt: 根标签不是 Compound")
+	ErrUnknownCodec = errors.New("nbt: 无法识别的压缩格式")
+)
+
+// Decoder NBT 解码器
+// 解码后的值类型:
+// Byte -> int8, Short -> int16, Int -> int32, Long -> int64, Float -> float32, Double -> float64,
+// ByteArray -> []byte, String -> string, List -> []any, Compound -> Compound,
+// IntArray -> []int32, LongArray -> []int64
+type Decoder struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	buf   [8]byte
+}
+
+// NewDecoder 创建 Java 版使用的大端序解码器
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), order: binary.BigEndian}
+}
+
+// NewLittleEndianDecoder 创建基岩版使用的小端序解码器
+func NewLittleEndianDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), order: binary.LittleEndian}
+}
+
+// Decode 解码根标签 返回根标签的名称与内容
+func (d *Decoder) Decode() (string, Compound, error) {
+	tagType, err := d.readByte()
+	if err != nil {
+		return "", nil, err
+	}
+	if tagType != TagCompound {
+		return "", nil, ErrNotCompound
+	}
+
+	name, err := d.readString()
+	if err != nil {
+		return "", nil, err
+	}
+
+	value, err := d.readPayload(TagCompound, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, value.(Compound), nil
+}
+
+// Decode 解码未压缩的大端序 NBT 数据
+func Decode(r io.Reader) (Compound, error) {
+	_, root, err := NewDecoder(r).Decode()
+	return root, err
+}
+
+// DecodeBytes 自动识别 gzip / zlib / 未压缩 的 NBT 数据并解码
+func DecodeBytes(data []byte) (Compound, error) {
+	r, err := decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(r)
+}
+
+// ReadFile 读取并解码 NBT 文件 (例如 gzip 压缩的 level.dat)
+func ReadFile(path string) (Compound, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeBytes(data)
+}
+
+// decompress 根据文件头判断压缩格式
+func decompress(data []byte) (io.Reader, error) {
+	switch {
+	case len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b:
+		return gzip.NewReader(bytes.NewReader(data))
+	case len(data) >= 2 && data[0] == 0x78:
+		return zlib.NewReader(bytes.NewReader(data))
+	case len(data) >= 1 && data[0] == TagCompound:
+		return bytes.NewReader(data), nil
+	}
+	return nil, ErrUnknownCodec
+}
+
+func (d *Decoder) readPayload(tagType byte, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, ErrTooDeep
+	}
+
+	switch tagType {
+	case TagByte:
+		b, err := d.readByte()
+		return int8(b), err
+	case TagShort:
+		if err := d.readFull(2); err != nil {
+			return nil, err
+		}
+		return int16(d.order.Uint16(d.buf[:2])), nil
+	case TagInt:
+		if err := d.readFull(4); err != nil {
+			return nil, err
+		}
+		return int32(d.order.Uint32(d.buf[:4])), nil
+	case TagLong:
+		if err := d.readFull(8); err != nil {
+			return nil, err
+		}
+		return int64(d.order.Uint64(d.buf[:8])), nil
+	case TagFloat:
+		if err := d.readFull(4); err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(d.order.Uint32(d.buf[:4])), nil
+	case TagDouble:
+		if err := d.readFull(8); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(d.order.Uint64(d.buf[:8])), nil
+	case TagByteArray:
+		n, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, n)
+		_, err = io.ReadFull(d.r, data)
+		return data, err
+	case TagString:
+		return d.readString()
+	case TagList:
+		elemType, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		n, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		if elemType == TagEnd && n > 0 {
+			return nil, ErrInvalidTag
+		}
+		list := make([]any, 0, min(n, 1024))
+		for i := 0; i < n; i++ {
+			value, err := d.readPayload(elemType, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case TagCompound:
+		compound := make(Compound)
+		for {
+			childType, err := d.readByte()
+			if err != nil {
+				return nil, err
+			}
+			if childType == TagEnd {
+				return compound, nil
+			}
+			name, err := d.readString()
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.readPayload(childType, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			compound[name] = value
+		}
+	case TagIntArray:
+		n, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		array := make([]int32, n)
+		for i := range array {
+			if err := d.readFull(4); err != nil {
+				return nil, err
+			}
+			array[i] = int32(d.order.Uint32(d.buf[:4]))
+		}
+		return array, nil
+	case TagLongArray:
+		n, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		array := make([]int64, n)
+		for i := range array {
+			if err := d.readFull(8); err != nil {
+				return nil, err
+			}
+			array[i] = int64(d.order.Uint64(d.buf[:8]))
+		}
+		return array, nil
+	}
+
+	return nil, fmt.Errorf("%w: %d", ErrInvalidTag, tagType)
+}
+
+func (d *Decoder) readByte() (byte, error) {
+	return d.r.ReadByte()
+}
+
+func (d *Decoder) readFull(n int) error {
+	_, err := io.ReadFull(d.r, d.buf[:n])
+	return err
+}
+
+// readLength 读取数组或列表的长度
+func (d *Decoder) readLength() (int, error) {
+	if err := d.readFull(4); err != nil {
+		return 0, err
+	}
+	n := int32(d.order.Uint32(d.buf[:4]))
+	if n < 0 {
+		return 0, nil
+	}
+	if n > maxArrayLen {
+		return 0, ErrTooLarge
+	}
+	return int(n), nil
+}
+
+// readString 读取带 2 字节长度前缀的字符串 (Java 的 Modified UTF-8 对常见字符与 UTF-8 相同)
+func (d *Decoder) readString() (string, error) {
+	if err := d.readFull(2); err != nil {
+		return "", err
+	}
+	data := make([]byte, d.order.Uint16(d.buf[:2]))
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,71 @@
+package region
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Problem 区域文件中发现的问题 Index 为 -1 表示整个文件的问题
+type Problem struct {
+	Index   int
+	Message string
+	Fatal   bool // 区块数据无法读取 否则只是浪费空间等不影响游戏的问题
+}
+
+// Check 检查文件头中的位置表与区块数据的长度 不解压区块
+// 检查内容: 起始扇区是否位于文件头之后、扇区是否超出文件、不同区块的扇区是否重叠、区块长度是否有效
+func Check(data []byte) []Problem {
+	if len(data) == 0 {
+		return nil
+	}
+
+	header, err := ParseHeader(data)
+	if err != nil {
+		return []Problem{{Index: -1, Message: err.Error(), Fatal: true}}
+	}
+
+	var problems []Problem
+	if len(data)%SectorSize != 0 {
+		problems = append(problems, Problem{Index: -1, Message: fmt.Sprintf("文件大小 %d 不是扇区大小的整数倍", len(data))})
+	}
+
+	var sectors = (len(data) + SectorSize - 1) / SectorSize
+	var owners = make([]int, sectors) // 扇区属于哪个区块 (索引+1)
+	for i, location := range header.Locations {
+		if !location.Exists() {
+			continue
+		}
+
+		var start, end = int(location.Offset), int(location.Offset) + int(location.Sectors)
+		switch {
+		case start < HeaderSize/SectorSize:
+			problems = append(problems, Problem{Index: i, Message: "起始扇区位于文件头中", Fatal: true})
+			continue
+		case end > sectors:
+			problems = append(problems, Problem{Index: i, Message: "区块超出文件末尾 (文件被截断)", Fatal: true})
+			continue
+		}
+
+		for sector := start; sector < end; sector++ {
+			if owner := owners[sector]; owner != 0 {
+				problems = append(problems, Problem{Index: i, Message: fmt.Sprintf("与区块 %d 的扇区重叠", owner-1), Fatal: true})
+				break
+			}
+			owners[sector] = i + 1
+		}
+
+		var offset = start * SectorSize
+		if offset+chunkHeaderSize > len(data) {
+			problems = append(problems, Problem{Index: i, Message: "区块超出文件末尾 (文件被截断)", Fatal: true})
+			continue
+		}
+		var length = int(binary.BigEndian.Uint32(data[offset:]))
+		switch {
+		case length < 1 || 4+length > int(location.Sectors)*SectorSize:
+			problems = append(problems, Problem{Index: i, Message: fmt.Sprintf("区块长度 %d 无效", length), Fatal: true})
+		case offset+4+length > len(data):
+			problems = append(problems, Problem{Index: i, Message: "区块数据不完整 (文件被截断)", Fatal: true})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,166 @@
+package region
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 区块数据前 5 个字节为 数据长度(4字节) 与 压缩方式(1字节)
+const (
+	chunkHeaderSize = 5
+	// externalFlag 压缩方式的最高位表示区块过大 数据存放在单独的 c.X.Z.mcc 文件中
+	externalFlag = 0x80
+)
+
+var ErrCorruptChunk = errors.New("region: 区块数据损坏")
+
+// File 内存中的区域文件 写回时会重新紧凑排列所有区块
+type File struct {
+	Timestamps [ChunksPerRegion]uint32
+	chunks     [ChunksPerRegion][]byte // 含 5 字节头的区块数据 不含扇区填充
+}
+
+// Parse 解析整个区域文件 空数据视为没有区块的区域
+func Parse(data []byte) (*File, error) {
+	header, err := ParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var file = &File{Timestamps: header.Timestamps}
+	for i, location := range header.Locations {
+		if !location.Exists() {
+			file.Timestamps[i] = 0
+			continue
+		}
+
+		var start = int64(location.Offset) * SectorSize
+		if start+chunkHeaderSize > int64(len(data)) {
+			return nil, fmt.Errorf("%w: 区块 %d 超出文件范围", ErrCorruptChunk, i)
+		}
+		var length = int64(binary.BigEndian.Uint32(data[start:]))
+		var end = start + 4 + length
+		if length < 1 || end > int64(len(data)) || end-start > int64(location.Sectors)*SectorSize {
+			return nil, fmt.Errorf("%w: 区块 %d 的长度无效", ErrCorruptChunk, i)
+		}
+		file.chunks[i] = data[start:end]
+	}
+	return file, nil
+}
+
+// ReadFile 读取区域文件 文件不存在时返回空的区域
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Has 区块是否存在
+func (f *File) Has(index int) bool {
+	return f.chunks[index] != nil
+}
+
+// Len 区域中区块的数量
+func (f *File) Len() int {
+	var count int
+	for _, chunk := range f.chunks {
+		if chunk != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Chunk 区块的原始数据 (含 5 字节头) 不存在时返回 nil
+func (f *File) Chunk(index int) []byte {
+	return f.chunks[index]
+}
+
+// IsExternal 区块数据是否存放在单独的 .mcc 文件中
+func (f *File) IsExternal(index int) bool {
+	var chunk = f.chunks[index]
+	return len(chunk) >= chunkHeaderSize && chunk[4]&externalFlag != 0
+}
+
+// Payload 区块的压缩数据 (不含 5 字节头) 区块不存在或存放在 .mcc 文件中时返回 nil
+// 数据可以直接交给 nbt.DecodeBytes 解码 (gzip / zlib / 未压缩)
+func (f *File) Payload(index int) []byte {
+	if !f.Has(index) || f.IsExternal(index) {
+		return nil
+	}
+	return f.chunks[index][chunkHeaderSize:]
+}
+
+// SetChunk 替换区块 data 为含 5 字节头的原始数据
+func (f *File) SetChunk(index int, data []byte, timestamp uint32) {
+	f.chunks[index] = data
+	f.Timestamps[index] = timestamp
+}
+
+// RemoveChunk 删除区块 游戏下次加载时会重新生成
+func (f *File) RemoveChunk(index int) {
+	f.chunks[index] = nil
+	f.Timestamps[index] = 0
+}
+
+// Bytes 按扇区重新排列所有区块 生成完整的区域文件
+func (f *File) Bytes() ([]byte, error) {
+	var locations [ChunksPerRegion]uint32
+	var body []byte
+	var sector = HeaderSize / SectorSize
+
+	for i, chunk := range f.chunks {
+		if chunk == nil {
+			continue
+		}
+
+		var sectors = (len(chunk) + SectorSize - 1) / SectorSize
+		if sectors > 0xff {
+			return nil, fmt.Errorf("%w: 区块 %d 超过 255 个扇区", ErrCorruptChunk, i)
+		}
+		locations[i] = uint32(sector)<<8 | uint32(sectors)
+
+		body = append(body, chunk...)
+		body = append(body, make([]byte, sectors*SectorSize-len(chunk))...)
+		sector += sectors
+	}
+
+	var data = make([]byte, HeaderSize, HeaderSize+len(body))
+	for i := 0; i < ChunksPerRegion; i++ {
+		binary.BigEndian.PutUint32(data[i*4:], locations[i])
+		binary.BigEndian.PutUint32(data[SectorSize+i*4:], f.Timestamps[i])
+	}
+	return append(data, body...), nil
+}
+
+// WriteFile 先写入临时文件 再重命名覆盖 避免写入中断损坏区域文件
+func WriteFile(path string, f *File) error {
+	data, err := f.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var tmp = path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ExternalFileName 过大区块的单独文件名 例如 c.-33.5.mcc
+func ExternalFileName(chunkX, chunkZ int) string {
+	return fmt.Sprintf("c.%d.%d.mcc", chunkX, chunkZ)
+}
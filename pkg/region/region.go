@@ -0,0 +1,109 @@
+package region
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// 区域文件 (.mca) 的格式
+// 文件开头是两张 4KiB 的表: 区块位置表 与 区块时间戳表 每张表 1024 项 对应区域中 32x32 个区块
+const (
+	SectorSize      = 4096
+	RegionWidth     = 32
+	ChunksPerRegion = RegionWidth * RegionWidth
+	HeaderSize      = SectorSize * 2
+)
+
+var ErrHeaderTooShort = errors.New("region: 文件头不完整")
+
+// Location 区块在文件中的位置
+type Location struct {
+	Offset  uint32 // 起始扇区
+	Sectors uint8  // 占用的扇区数
+}
+
+// Exists 区块是否已生成
+func (l Location) Exists() bool {
+	return l.Offset != 0 && l.Sectors != 0
+}
+
+// Header 区域文件的文件头
+type Header struct {
+	Locations  [ChunksPerRegion]Location
+	Timestamps [ChunksPerRegion]uint32 // 区块最后一次保存的时间(秒)
+}
+
+// ParseHeader 解析区域文件头
+// 空文件 (游戏创建后尚未写入任何区块) 视为没有区块
+func ParseHeader(data []byte) (*Header, error) {
+	var header Header
+	if len(data) == 0 {
+		return &header, nil
+	}
+	if len(data) < HeaderSize {
+		return nil, ErrHeaderTooShort
+	}
+
+	for i := 0; i < ChunksPerRegion; i++ {
+		var entry = binary.BigEndian.Uint32(data[i*4:])
+		header.Locations[i] = Location{
+			Offset:  entry >> 8,
+			Sectors: uint8(entry),
+		}
+		header.Timestamps[i] = binary.BigEndian.Uint32(data[SectorSize+i*4:])
+	}
+	return &header, nil
+}
+
+// ReadHeader 从区域文件中读取文件头
+func ReadHeader(r io.Reader) (*Header, error) {
+	var data = make([]byte, HeaderSize)
+	n, err := io.ReadFull(r, data)
+	if n == 0 && (err == io.EOF || err == io.ErrUnexpectedEOF) {
+		return &Header{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseHeader(data)
+}
+
+// Time 区块最后一次保存的时间 区块不存在时返回零值
+func (h *Header) Time(index int) time.Time {
+	if !h.Locations[index].Exists() || h.Timestamps[index] == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(h.Timestamps[index]), 0)
+}
+
+// ChunkIndex 区域内区块坐标对应的表项下标 坐标可以是世界区块坐标
+func ChunkIndex(chunkX, chunkZ int) int {
+	return (chunkX & (RegionWidth - 1)) + (chunkZ&(RegionWidth-1))*RegionWidth
+}
+
+// ChunkPos 区域坐标与表项下标对应的世界区块坐标
+func ChunkPos(regionX, regionZ, index int) (chunkX, chunkZ int) {
+	return regionX*RegionWidth + index%RegionWidth, regionZ*RegionWidth + index/RegionWidth
+}
+
+// RegionPos 区块所在的区域坐标
+func RegionPos(chunkX, chunkZ int) (regionX, regionZ int) {
+	return chunkX >> 5, chunkZ >> 5
+}
+
+// FileName 区域文件名 例如 r.-1.0.mca
+func FileName(regionX, regionZ int) string {
+	return fmt.Sprintf("r.%d.%d.mca", regionX, regionZ)
+}
+
+// ParseFileName 从区域文件名中解析区域坐标
+func ParseFileName(name string) (regionX, regionZ int, ok bool) {
+	var ext string
+	if n, err := fmt.Sscanf(name, "r.%d.%d.%s", &regionX, &regionZ, &ext); err != nil || n != 3 || ext != "mca" {
+		return 0, 0, false
+	}
+	return regionX, regionZ, true
+}
@@ -2,6 +2,8 @@ package core
 
 import (
 	"log"
+	"minecraft-archive-backup/pkg/task/metadata_backup"
+	"minecraft-archive-backup/pkg/task/version_watch"
 	"time"
 )
 
@@ -15,20 +17,29 @@ func init() {
 	// 添加 RemoveExpiredImage 删除 过期的图片 任务
 	//TaskMenger.AddTask(&remove_expired_image.RemoveExpiredImage{})
 
+	// 添加 MetadataBackup 定时备份程序数据 任务
+	TaskMenger.AddTask(&metadata_backup.MetadataBackup{})
+
+	// 添加 VersionWatch 游戏升级存档时固定升级前的快照 任务
+	TaskMenger.AddTask(&version_watch.VersionWatch{})
+
 	// 在 Range 内部启动 携程 循环的执行定时任务
 	TaskMenger.Range(func(task Task) {
 		// 判断是否立即执行
 		if task.ExecuteImmediately() {
 			log.Printf("[ %s ]执行任务!\n", task.Key())
-			task.Run()
+			TaskMenger.Run(task)
 		}
-		// 定时器
+		// 定时器 停止后退出
 		var tick = time.NewTicker(task.Interval())
+		defer tick.Stop()
 		for {
 			select {
 			case <-tick.C:
 				log.Printf("[ %s ]执行任务!\n", task.Key())
-				task.Run()
+				TaskMenger.Run(task)
+			case <-TaskMenger.Stopped():
+				return
 			}
 		}
 	})
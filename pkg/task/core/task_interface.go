@@ -9,6 +9,10 @@ import (
 type TaskMachine struct {
 	mu    sync.RWMutex
 	tasks map[string]Task
+
+	stopped bool
+	stop    chan struct{}  // 停止后关闭 循环中的定时器随之退出
+	running sync.WaitGroup // 正在执行的任务
 }
 
 type Task interface {
@@ -25,6 +29,7 @@ type Task interface {
 func NewTaskMachine() *TaskMachine {
 	return &TaskMachine{
 		tasks: make(map[string]Task),
+		stop:  make(chan struct{}),
 	}
 }
 
@@ -50,3 +55,34 @@ func (t *TaskMachine) Range(fn func(task Task)) {
 		go fn(task)
 	}
 }
+
+// Stopped 停止后关闭的通道
+func (t *TaskMachine) Stopped() <-chan struct{} {
+	return t.stop
+}
+
+// Run 执行一次任务 已经停止时不再执行
+func (t *TaskMachine) Run(task Task) {
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return
+	}
+	t.running.Add(1)
+	t.mu.Unlock()
+	defer t.running.Done()
+
+	task.Run()
+}
+
+// Stop 停止所有任务 并等待正在执行的任务结束 之后不会再执行任何任务
+func (t *TaskMachine) Stop() {
+	t.mu.Lock()
+	if !t.stopped {
+		t.stopped = true
+		close(t.stop)
+	}
+	t.mu.Unlock()
+
+	t.running.Wait()
+}
@@ -0,0 +1,42 @@
+package metadata_backup
+
+import (
+	"errors"
+	"log"
+	"minecraft-archive-backup/internal/archive"
+	"time"
+)
+
+// MetadataBackup 定时将数据库与配置文件备份到仓库中
+type MetadataBackup struct{}
+
+func (m *MetadataBackup) Key() string {
+	return "MetadataBackup"
+}
+
+func (m *MetadataBackup) Interval() time.Duration {
+	return time.Hour
+}
+
+func (m *MetadataBackup) Run() {
+	summary, err := archive.BackupMetadata()
+	if errors.Is(err, archive.ErrNoArchives) {
+		log.Printf("[ %s ]还没有任何存档 跳过备份\n", m.Key())
+		return
+	}
+	if err != nil {
+		log.Printf("[ %s ]备份程序数据失败: %v\n", m.Key(), err)
+		return
+	}
+
+	// 数据没有变化 跳过同样视为成功
+	if summary.Skipped() {
+		log.Printf("[ %s ]程序数据没有变化 跳过备份\n", m.Key())
+		return
+	}
+	log.Printf("[ %s ]程序数据已备份 快照ID: %s\n", m.Key(), summary.SnapshotID)
+}
+
+func (m *MetadataBackup) ExecuteImmediately() bool {
+	return true
+}
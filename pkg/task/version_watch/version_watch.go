@@ -0,0 +1,28 @@
+package version_watch
+
+import (
+	"log"
+	"minecraft-archive-backup/internal/archive"
+	"time"
+)
+
+// VersionWatch 监听存档的游戏版本 定时同步需要监听的存档
+type VersionWatch struct{}
+
+func (v *VersionWatch) Key() string {
+	return "VersionWatch"
+}
+
+func (v *VersionWatch) Interval() time.Duration {
+	return time.Minute
+}
+
+func (v *VersionWatch) Run() {
+	if err := archive.WatchGameVersions(); err != nil {
+		log.Printf("[ %s ]同步监听的存档失败: %v\n", v.Key(), err)
+	}
+}
+
+func (v *VersionWatch) ExecuteImmediately() bool {
+	return true
+}
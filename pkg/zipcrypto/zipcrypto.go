@@ -0,0 +1,147 @@
+package zipcrypto
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"crypto/rand"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// PKWARE 传统加密 (ZipCrypto) 强度较低 但 Windows 资源管理器与所有解压软件都支持
+
+// 通用标志位
+const (
+	flagEncrypted      = 0x1
+	flagDataDescriptor = 0x8   // 压缩后的大小与 CRC 写在数据之后 可以边压缩边写入
+	flagUTF8           = 0x800 // 文件名为 UTF-8 编码
+)
+
+// headerSize 数据前的加密头长度 压缩后的大小包含加密头
+const headerSize = 12
+
+// keys 加密状态
+type keys [3]uint32
+
+// newKeys 用密码初始化加密状态
+func newKeys(password string) *keys {
+	var k = &keys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		k.update(password[i])
+	}
+	return k
+}
+
+// update 用明文字节更新加密状态
+func (k *keys) update(b byte) {
+	k[0] = crc32Update(k[0], b)
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+// encrypt 加密一个字节
+func (k *keys) encrypt(b byte) byte {
+	var temp = uint32(uint16(k[2]) | 2)
+	var c = b ^ byte(temp*(temp^1)>>8)
+	k.update(b)
+	return c
+}
+
+// crc32Update 不做取反的单字节 CRC32
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ crc>>8
+}
+
+// encrypter 加密写入的数据
+type encrypter struct {
+	w     io.Writer
+	keys  *keys
+	buf   []byte
+	count int64 // 写入的密文字节数
+}
+
+func (e *encrypter) Write(p []byte) (int, error) {
+	if cap(e.buf) < len(p) {
+		e.buf = make([]byte, len(p))
+	}
+	var buf = e.buf[:len(p)]
+	for i, b := range p {
+		buf[i] = e.keys.encrypt(b)
+	}
+	n, err := e.w.Write(buf)
+	e.count += int64(n)
+	return n, err
+}
+
+// fileWriter 加密文件的写入器
+type fileWriter struct {
+	header    *zip.FileHeader
+	encrypter *encrypter
+	flate     *flate.Writer
+	crc       hash.Hash32
+	size      uint64
+}
+
+func (f *fileWriter) Write(p []byte) (int, error) {
+	f.crc.Write(p)
+	f.size += uint64(len(p))
+	return f.flate.Write(p)
+}
+
+// Close 结束压缩并写入 CRC 与大小 必须在创建下一个文件或关闭 zip 之前调用
+func (f *fileWriter) Close() error {
+	if err := f.flate.Close(); err != nil {
+		return err
+	}
+
+	var fh = f.header
+	fh.CRC32 = f.crc.Sum32()
+	fh.CompressedSize64 = uint64(f.encrypter.count)
+	fh.UncompressedSize64 = f.size
+	fh.CompressedSize = uint32(min(fh.CompressedSize64, math.MaxUint32))
+	fh.UncompressedSize = uint32(min(fh.UncompressedSize64, math.MaxUint32))
+	return nil
+}
+
+// Create 在 zip 中新建一个用密码加密的文件 使用 Deflate 压缩
+// 写完内容后必须调用 Close zip.Writer 会接管 fh
+func Create(zw *zip.Writer, fh *zip.FileHeader, password string) (io.WriteCloser, error) {
+	if password == "" {
+		return nil, errors.New("zipcrypto: 密码不能为空")
+	}
+
+	fh.Method = zip.Deflate
+	fh.Flags |= flagEncrypted | flagDataDescriptor | flagUTF8
+	fh.CreatorVersion, fh.ReaderVersion = 20, 20
+	if !fh.Modified.IsZero() {
+		var t = fh.Modified
+		fh.ModifiedDate = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+		fh.ModifiedTime = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	}
+
+	raw, err := zw.CreateRaw(fh)
+	if err != nil {
+		return nil, err
+	}
+
+	// 加密头为 11 个随机字节与 1 个校验字节 使用数据描述符时校验字节为修改时间的高位
+	var header = make([]byte, headerSize)
+	if _, err := rand.Read(header[:headerSize-1]); err != nil {
+		return nil, err
+	}
+	header[headerSize-1] = byte(fh.ModifiedTime >> 8)
+
+	var enc = &encrypter{w: raw, keys: newKeys(password)}
+	if _, err := enc.Write(header); err != nil {
+		return nil, err
+	}
+
+	fw, err := flate.NewWriter(enc, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	return &fileWriter{header: fh, encrypter: enc, flate: fw, crc: crc32.NewIEEE()}, nil
+}